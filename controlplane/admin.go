@@ -0,0 +1,54 @@
+package controlplane
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errAdminNotEnabled is returned by the admin RPCs when the Server wasn't
+// given an AdminPool.
+var errAdminNotEnabled = status.Error(codes.Unavailable, "admin API not enabled")
+
+// AdminPool is the minimal surface Server's admin RPCs need from the load
+// balancer's backend pool - drain backend, set weight - kept separate from
+// TrafficPolicyEngine/ConsulServiceManager (which Server already depends on
+// directly) so this package doesn't need to import package main's
+// ServerPool and risk an import cycle, since main is what constructs a
+// Server.
+type AdminPool interface {
+	// DrainBackend stops backendURL from taking new sticky sessions and
+	// evicts every session still assigned to it on this replica. found is
+	// false if backendURL isn't in the pool.
+	DrainBackend(backendURL string) (evicted int, found bool)
+	// SetBackendWeight updates backendURL's routing weight. Returns false if
+	// backendURL isn't in the pool.
+	SetBackendWeight(ctx context.Context, backendURL string, weight int) bool
+}
+
+func (s *Server) drainBackend(ctx context.Context, req *DrainBackendRequest) (*DrainBackendResponse, error) {
+	if s.adminPool == nil {
+		return nil, errAdminNotEnabled
+	}
+	evicted, found := s.adminPool.DrainBackend(req.BackendURL)
+	return &DrainBackendResponse{Found: found, SessionsEvicted: evicted}, nil
+}
+
+func (s *Server) setBackendWeight(ctx context.Context, req *SetBackendWeightRequest) (*SetBackendWeightResponse, error) {
+	if s.adminPool == nil {
+		return nil, errAdminNotEnabled
+	}
+	found := s.adminPool.SetBackendWeight(ctx, req.BackendURL, req.Weight)
+	return &SetBackendWeightResponse{Found: found}, nil
+}
+
+func (s *Server) reloadPolicies(ctx context.Context, req *ReloadPoliciesRequest) (*ReloadPoliciesResponse, error) {
+	if s.policyEngine == nil {
+		return nil, status.Error(codes.Unavailable, "traffic policies not enabled")
+	}
+	if err := s.policyEngine.ReplacePolicies(req.Policies); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &ReloadPoliciesResponse{Count: len(req.Policies)}, nil
+}
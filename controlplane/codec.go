@@ -0,0 +1,22 @@
+package controlplane
+
+import "encoding/json"
+
+// jsonCodec implements grpc/encoding.Codec over plain JSON. The control-plane
+// services in this package are hand-maintained Go structs rather than
+// protoc-gen-go output (there's no protobuf codegen step in this repo), so
+// the server is configured with grpc.ForceServerCodec(jsonCodec{}) instead of
+// relying on grpc's default protobuf codec.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
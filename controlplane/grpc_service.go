@@ -0,0 +1,208 @@
+package controlplane
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Hand-written handler wrappers and ServiceDesc values below stand in for
+// protoc-gen-go-grpc output; there's no protobuf codegen step in this repo,
+// so the wiring that generator would normally produce is maintained by hand.
+
+func _TrafficPolicyService_AddPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).addPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.TrafficPolicyService/AddPolicy"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).addPolicy(ctx, req.(*AddPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrafficPolicyService_EnablePolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnablePolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).enablePolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.TrafficPolicyService/EnablePolicy"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).enablePolicy(ctx, req.(*EnablePolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrafficPolicyService_GetPolicies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPoliciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).getPolicies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.TrafficPolicyService/GetPolicies"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).getPolicies(ctx, req.(*GetPoliciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrafficPolicyService_EvaluateRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvaluateRequestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).evaluateRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.TrafficPolicyService/EvaluateRequest"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).evaluateRequest(ctx, req.(*EvaluateRequestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var trafficPolicyServiceDesc = grpc.ServiceDesc{
+	ServiceName: "controlplane.TrafficPolicyService",
+	HandlerType: (*trafficPolicyServiceHandler)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "AddPolicy", Handler: _TrafficPolicyService_AddPolicy_Handler},
+		{MethodName: "EnablePolicy", Handler: _TrafficPolicyService_EnablePolicy_Handler},
+		{MethodName: "GetPolicies", Handler: _TrafficPolicyService_GetPolicies_Handler},
+		{MethodName: "EvaluateRequest", Handler: _TrafficPolicyService_EvaluateRequest_Handler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/controlplane.proto",
+}
+
+// trafficPolicyServiceHandler is a marker type for grpc.ServiceDesc.HandlerType;
+// *Server implements it by satisfying the methods above, but the methods
+// themselves stay unexported since they're only reachable through the
+// registered ServiceDesc.
+type trafficPolicyServiceHandler interface{}
+
+func _ServiceDiscoveryService_GetAllServices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAllServicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).getAllServices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.ServiceDiscoveryService/GetAllServices"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).getAllServices(ctx, req.(*GetAllServicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceDiscoveryService_GetServicesByRegion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServicesByRegionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).getServicesByRegion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.ServiceDiscoveryService/GetServicesByRegion"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).getServicesByRegion(ctx, req.(*GetServicesByRegionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ServiceDiscoveryService_WatchServices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(WatchServicesRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(*Server).watchServices(in, stream)
+}
+
+var serviceDiscoveryServiceDesc = grpc.ServiceDesc{
+	ServiceName: "controlplane.ServiceDiscoveryService",
+	HandlerType: (*serviceDiscoveryServiceHandler)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "GetAllServices", Handler: _ServiceDiscoveryService_GetAllServices_Handler},
+		{MethodName: "GetServicesByRegion", Handler: _ServiceDiscoveryService_GetServicesByRegion_Handler},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchServices",
+			Handler:       _ServiceDiscoveryService_WatchServices_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/controlplane.proto",
+}
+
+// serviceDiscoveryServiceHandler is a marker type for grpc.ServiceDesc.HandlerType.
+type serviceDiscoveryServiceHandler interface{}
+
+func _AdminService_DrainBackend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainBackendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).drainBackend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.AdminService/DrainBackend"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).drainBackend(ctx, req.(*DrainBackendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetBackendWeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetBackendWeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).setBackendWeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.AdminService/SetBackendWeight"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).setBackendWeight(ctx, req.(*SetBackendWeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ReloadPolicies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadPoliciesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(*Server).reloadPolicies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/controlplane.AdminService/ReloadPolicies"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*Server).reloadPolicies(ctx, req.(*ReloadPoliciesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var adminServiceDesc = grpc.ServiceDesc{
+	ServiceName: "controlplane.AdminService",
+	HandlerType: (*adminServiceHandler)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "DrainBackend", Handler: _AdminService_DrainBackend_Handler},
+		{MethodName: "SetBackendWeight", Handler: _AdminService_SetBackendWeight_Handler},
+		{MethodName: "ReloadPolicies", Handler: _AdminService_ReloadPolicies_Handler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/controlplane.proto",
+}
+
+// adminServiceHandler is a marker type for grpc.ServiceDesc.HandlerType.
+type adminServiceHandler interface{}
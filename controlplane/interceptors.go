@@ -0,0 +1,71 @@
+package controlplane
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// activeRPCs tracks in-flight control-plane RPCs per method, exposed
+// alongside the rest of the load balancer's Prometheus series on /metrics.
+var activeRPCs = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "loadbalancer_grpc_active_rpcs",
+		Help: "Number of in-flight gRPC control-plane calls per method",
+	},
+	[]string{"method"},
+)
+
+// RegisterMetrics registers this package's collectors against reg. Call once
+// during startup alongside the rest of the load balancer's Prometheus
+// registration.
+func RegisterMetrics(reg *prometheus.Registry) {
+	reg.MustRegister(activeRPCs)
+}
+
+// recoveryUnaryInterceptor converts a panic inside a unary handler into a
+// codes.Internal error (logging the stack trace) instead of tearing down the
+// process, mirroring the go-grpc-middleware recovery interceptor.
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[ERROR] panic in gRPC handler %s: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Errorf(codes.Internal, "internal error handling %s", info.FullMethod)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor is the streaming-RPC equivalent of
+// recoveryUnaryInterceptor.
+func recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[ERROR] panic in gRPC stream handler %s: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Errorf(codes.Internal, "internal error handling %s", info.FullMethod)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// countingUnaryInterceptor tracks activeRPCs for the duration of a unary call.
+func countingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	gauge := activeRPCs.WithLabelValues(info.FullMethod)
+	gauge.Inc()
+	defer gauge.Dec()
+	return handler(ctx, req)
+}
+
+// countingStreamInterceptor is the streaming-RPC equivalent of
+// countingUnaryInterceptor.
+func countingStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	gauge := activeRPCs.WithLabelValues(info.FullMethod)
+	gauge.Inc()
+	defer gauge.Dec()
+	return handler(srv, ss)
+}
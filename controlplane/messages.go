@@ -0,0 +1,103 @@
+package controlplane
+
+import "github.com/tushar-xo/Load-Balancer-GO/loadbalancer"
+
+// Request/response types for the control-plane gRPC services defined in
+// proto/controlplane.proto. There's no protoc codegen step in this repo yet,
+// so these are hand-maintained plain Go structs carried over the wire by the
+// JSON codec defined in codec.go, rather than protoc-gen-go output.
+
+// AddPolicyRequest carries a policy to register with the TrafficPolicyEngine.
+type AddPolicyRequest struct {
+	Policy loadbalancer.TrafficPolicy `json:"policy"`
+}
+
+// AddPolicyResponse is empty; a non-nil error means the add failed.
+type AddPolicyResponse struct{}
+
+// EnablePolicyRequest toggles a policy by name.
+type EnablePolicyRequest struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// EnablePolicyResponse reports whether a matching policy was found.
+type EnablePolicyResponse struct {
+	Found bool `json:"found"`
+}
+
+// GetPoliciesRequest takes no parameters.
+type GetPoliciesRequest struct{}
+
+// GetPoliciesResponse lists every registered policy.
+type GetPoliciesResponse struct {
+	Policies []loadbalancer.TrafficPolicy `json:"policies"`
+}
+
+// EvaluateRequestRequest describes the synthetic request to dry-run against
+// every registered policy.
+type EvaluateRequestRequest struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers"`
+	ClientIP string            `json:"client_ip"`
+}
+
+// EvaluateRequestResponse reports whether a policy matched and, best-effort,
+// a description of the backend it selected.
+type EvaluateRequestResponse struct {
+	Matched bool   `json:"matched"`
+	Backend string `json:"backend"`
+}
+
+// GetAllServicesRequest takes no parameters.
+type GetAllServicesRequest struct{}
+
+// GetServicesByRegionRequest filters by region.
+type GetServicesByRegionRequest struct {
+	Region string `json:"region"`
+}
+
+// GetAllServicesResponse lists currently discovered services; also the
+// payload streamed by WatchServices on every change.
+type GetAllServicesResponse struct {
+	Services []loadbalancer.ConsulService `json:"services"`
+}
+
+// WatchServicesRequest takes no parameters.
+type WatchServicesRequest struct{}
+
+// DrainBackendRequest names the backend to drain.
+type DrainBackendRequest struct {
+	BackendURL string `json:"backend_url"`
+}
+
+// DrainBackendResponse reports whether backendURL was found and how many
+// sticky sessions were evicted from it.
+type DrainBackendResponse struct {
+	Found           bool `json:"found"`
+	SessionsEvicted int  `json:"sessions_evicted"`
+}
+
+// SetBackendWeightRequest updates one backend's routing weight.
+type SetBackendWeightRequest struct {
+	BackendURL string `json:"backend_url"`
+	Weight     int    `json:"weight"`
+}
+
+// SetBackendWeightResponse reports whether a matching backend was found.
+type SetBackendWeightResponse struct {
+	Found bool `json:"found"`
+}
+
+// ReloadPoliciesRequest carries the full traffic-policy set to replace the
+// engine's current one with.
+type ReloadPoliciesRequest struct {
+	Policies []loadbalancer.TrafficPolicy `json:"policies"`
+}
+
+// ReloadPoliciesResponse reports how many policies are in effect after the
+// reload.
+type ReloadPoliciesResponse struct {
+	Count int `json:"count"`
+}
@@ -0,0 +1,137 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+
+	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errConsulNotEnabled is returned by the ServiceDiscoveryService methods when
+// the Server wasn't given a ConsulServiceManager.
+var errConsulNotEnabled = status.Error(codes.Unavailable, "consul service discovery not enabled")
+
+// Server implements TrafficPolicyService, ServiceDiscoveryService, and
+// AdminService on top of an existing TrafficPolicyEngine, ConsulServiceManager,
+// and AdminPool, giving operators a stable programmatic API for CI/CD-driven
+// policy rollouts, service-discovery introspection, and backend draining/
+// reweighting.
+type Server struct {
+	policyEngine  *loadbalancer.TrafficPolicyEngine
+	consulManager *loadbalancer.ConsulServiceManager
+	adminPool     AdminPool
+}
+
+// NewServer creates a Server backed by policyEngine and consulManager.
+// consulManager may be nil if Consul support isn't enabled; the
+// ServiceDiscoveryService methods then return codes.Unavailable.
+func NewServer(policyEngine *loadbalancer.TrafficPolicyEngine, consulManager *loadbalancer.ConsulServiceManager) *Server {
+	return &Server{policyEngine: policyEngine, consulManager: consulManager}
+}
+
+// WithAdminPool attaches adminPool so the AdminService RPCs (DrainBackend,
+// SetBackendWeight) can act on the live backend pool; without it they return
+// codes.Unavailable. Returns s for chaining onto NewServer.
+func (s *Server) WithAdminPool(adminPool AdminPool) *Server {
+	s.adminPool = adminPool
+	return s
+}
+
+// NewGRPCServer builds a *grpc.Server exposing s's services, guarded by a
+// unary/stream interceptor chain that recovers panics (converting them to
+// codes.Internal instead of crashing the process) ahead of a counter that
+// tracks active RPCs for the /metrics endpoint.
+func (s *Server) NewGRPCServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ForceServerCodec(jsonCodec{}),
+		grpc.ChainUnaryInterceptor(recoveryUnaryInterceptor, countingUnaryInterceptor),
+		grpc.ChainStreamInterceptor(recoveryStreamInterceptor, countingStreamInterceptor),
+	)
+	server.RegisterService(&trafficPolicyServiceDesc, s)
+	server.RegisterService(&serviceDiscoveryServiceDesc, s)
+	server.RegisterService(&adminServiceDesc, s)
+	return server
+}
+
+func (s *Server) addPolicy(ctx context.Context, req *AddPolicyRequest) (*AddPolicyResponse, error) {
+	if err := s.policyEngine.AddPolicy(req.Policy); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &AddPolicyResponse{}, nil
+}
+
+func (s *Server) enablePolicy(ctx context.Context, req *EnablePolicyRequest) (*EnablePolicyResponse, error) {
+	found := s.policyEngine.EnablePolicy(req.Name, req.Enabled)
+	return &EnablePolicyResponse{Found: found}, nil
+}
+
+func (s *Server) getPolicies(ctx context.Context, req *GetPoliciesRequest) (*GetPoliciesResponse, error) {
+	return &GetPoliciesResponse{Policies: s.policyEngine.GetPolicies()}, nil
+}
+
+// evaluateRequest builds a synthetic *http.Request from req and dry-runs it
+// through the policy engine, so CI/CD tooling can check a policy rollout's
+// effect before real traffic sees it.
+func (s *Server) evaluateRequest(ctx context.Context, req *EvaluateRequestRequest) (*EvaluateRequestResponse, error) {
+	httpReq := httptest.NewRequest(req.Method, req.Path, nil)
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	if req.ClientIP != "" {
+		httpReq.RemoteAddr = fmt.Sprintf("%s:0", req.ClientIP)
+	}
+
+	backend, _, err := s.policyEngine.EvaluateRequest(httpReq)
+	if err != nil {
+		return &EvaluateRequestResponse{Matched: false, Backend: err.Error()}, nil
+	}
+	if backend == nil {
+		return &EvaluateRequestResponse{Matched: false}, nil
+	}
+	return &EvaluateRequestResponse{Matched: true, Backend: fmt.Sprintf("%v", backend)}, nil
+}
+
+func (s *Server) getAllServices(ctx context.Context, req *GetAllServicesRequest) (*GetAllServicesResponse, error) {
+	if s.consulManager == nil {
+		return nil, errConsulNotEnabled
+	}
+	return &GetAllServicesResponse{Services: s.consulManager.GetAllServices()}, nil
+}
+
+func (s *Server) getServicesByRegion(ctx context.Context, req *GetServicesByRegionRequest) (*GetAllServicesResponse, error) {
+	if s.consulManager == nil {
+		return nil, errConsulNotEnabled
+	}
+	return &GetAllServicesResponse{Services: s.consulManager.GetServicesByRegion(req.Region)}, nil
+}
+
+// watchServices streams a GetAllServicesResponse snapshot immediately, then
+// again every time ConsulServiceManager.NotifyOnChange fires, until the
+// client disconnects.
+func (s *Server) watchServices(req *WatchServicesRequest, stream grpc.ServerStream) error {
+	if s.consulManager == nil {
+		return errConsulNotEnabled
+	}
+
+	changed := s.consulManager.NotifyOnChange()
+	ctx := stream.Context()
+
+	if err := stream.SendMsg(&GetAllServicesResponse{Services: s.consulManager.GetAllServices()}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+			if err := stream.SendMsg(&GetAllServicesResponse{Services: s.consulManager.GetAllServices()}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newEjectionTestBackend(t *testing.T) *Backend {
+	t.Helper()
+	u, err := url.Parse("http://backend-ejection-test")
+	if err != nil {
+		t.Fatalf("failed to parse url: %v", err)
+	}
+	return &Backend{URL: u}
+}
+
+func TestBackendErrorRateComputesFailureFraction(t *testing.T) {
+	b := newEjectionTestBackend(t)
+	now := time.Now()
+	b.errorWindow = []errorSample{
+		{at: now, success: true},
+		{at: now, success: false},
+		{at: now, success: false},
+		{at: now, success: true},
+	}
+
+	rate, samples := b.errorRate(now, time.Minute)
+	if samples != 4 {
+		t.Fatalf("expected 4 samples within the window, got %d", samples)
+	}
+	if rate != 0.5 {
+		t.Fatalf("expected a 0.5 error rate, got %v", rate)
+	}
+}
+
+func TestBackendErrorRateTrimsSamplesOutsideWindow(t *testing.T) {
+	b := newEjectionTestBackend(t)
+	now := time.Now()
+	b.errorWindow = []errorSample{
+		{at: now.Add(-time.Hour), success: false},
+		{at: now, success: true},
+	}
+
+	rate, samples := b.errorRate(now, time.Minute)
+	if samples != 1 {
+		t.Fatalf("expected the stale sample to be trimmed, leaving 1, got %d", samples)
+	}
+	if rate != 0 {
+		t.Fatalf("expected a 0 error rate once only the success sample remains, got %v", rate)
+	}
+
+	if len(b.errorWindow) != 1 {
+		t.Fatalf("expected errorRate to trim errorWindow in place, got length %d", len(b.errorWindow))
+	}
+}
+
+func TestBackendErrorRateWithNoSamples(t *testing.T) {
+	b := newEjectionTestBackend(t)
+	rate, samples := b.errorRate(time.Now(), time.Minute)
+	if samples != 0 || rate != 0 {
+		t.Fatalf("expected 0 samples and 0 rate for an empty window, got rate=%v samples=%d", rate, samples)
+	}
+}
+
+func TestBackendEjectAndReinstate(t *testing.T) {
+	b := newEjectionTestBackend(t)
+	now := time.Now()
+	backoff := func(count int) time.Duration { return time.Duration(count) * time.Second }
+
+	if b.isEjected(now) {
+		t.Fatal("expected a fresh backend not to be ejected")
+	}
+
+	count := b.eject(now, backoff)
+	if count != 1 {
+		t.Fatalf("expected the first ejection to report count 1, got %d", count)
+	}
+	if !b.isEjected(now) {
+		t.Fatal("expected the backend to be ejected immediately after eject")
+	}
+	if b.isEjected(now.Add(2 * time.Second)) {
+		t.Fatal("expected the ejection backoff to have elapsed after 2s (backoff was 1s)")
+	}
+
+	count = b.eject(now, backoff)
+	if count != 2 {
+		t.Fatalf("expected ejectionCount to keep incrementing across ejections, got %d", count)
+	}
+
+	b.reinstate()
+	if b.isEjected(now) {
+		t.Fatal("expected reinstate to clear the ejection window")
+	}
+
+	// ejectionCount must survive reinstate so a future re-ejection keeps
+	// doubling the backoff from where it left off.
+	count = b.eject(now, backoff)
+	if count != 3 {
+		t.Fatalf("expected ejectionCount to continue from 2 after reinstate, got %d", count)
+	}
+}
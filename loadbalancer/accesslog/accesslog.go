@@ -0,0 +1,364 @@
+// Package accesslog provides an http.Handler middleware that records one
+// structured entry per request - client IP, timing, the selected upstream
+// backend, sticky session, region, circuit-breaker state, and matched
+// traffic-policy name - in either Apache Combined Log Format or JSON.
+// Entries are written through a bounded channel to a background goroutine,
+// so a slow disk never blocks the request path.
+package accesslog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Format selects how Writer renders an Entry to the log file.
+type Format string
+
+const (
+	// Common renders entries in Apache Combined Log Format, with this
+	// package's extra fields (backend, timings, session, region,
+	// circuit-breaker state, policy) appended as trailing key=value pairs.
+	Common Format = "common"
+	// JSON renders entries as one JSON object per line.
+	JSON Format = "json"
+)
+
+// Filter reports whether a request should be skipped - served normally but
+// not logged, e.g. health-check traffic. Checked in order; the first filter
+// to return true skips logging.
+type Filter func(r *http.Request) bool
+
+// Config configures a Writer.
+type Config struct {
+	// Format is Common or JSON; defaults to Common if empty.
+	Format Format
+	// FilePath is where access log lines are written. Required.
+	FilePath string
+	// BufferSize bounds the channel between request goroutines and the
+	// background writer; defaults to 1000. Once full, new entries are
+	// dropped (and, if EnableMetrics was called, counted) rather than
+	// blocking the request.
+	BufferSize int
+	// Filters are checked against every request; see Filter.
+	Filters []Filter
+}
+
+// Entry holds one request's access log fields. Middleware creates it with
+// what it can see from the outside (client IP, timestamp, method, URI,
+// protocol, user agent, referer, status, bytes, downstream duration);
+// handlers further down the chain attach what only they know - the selected
+// backend, its response time, sticky session ID, region, circuit-breaker
+// state, matched policy name - via the *Entry returned by FromContext.
+type Entry struct {
+	Timestamp          time.Time
+	ClientIP           string
+	Method             string
+	URI                string
+	Protocol           string
+	Status             int
+	BytesSent          int64
+	DownstreamDuration time.Duration
+	UserAgent          string
+	Referer            string
+
+	Backend             string
+	UpstreamDuration    time.Duration
+	SessionID           string
+	Region              string
+	CircuitBreakerState string
+	PolicyName          string
+}
+
+type ctxKey struct{}
+
+// NewContext returns a context carrying e, retrievable with FromContext.
+func NewContext(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, ctxKey{}, e)
+}
+
+// FromContext returns the Entry Middleware attached to ctx, or nil if the
+// request wasn't routed through Middleware.
+func FromContext(ctx context.Context) *Entry {
+	e, _ := ctx.Value(ctxKey{}).(*Entry)
+	return e
+}
+
+// Metrics is the Prometheus surface for a Writer's buffer health.
+type Metrics struct {
+	droppedTotal prometheus.Counter
+}
+
+// NewMetrics creates a Metrics and registers its collector against reg.
+func NewMetrics(reg *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		droppedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "lb_access_log_dropped_total",
+			Help: "Total number of access log entries dropped because the write buffer was full",
+		}),
+	}
+	reg.MustRegister(m.droppedTotal)
+	return m
+}
+
+// Writer owns the background goroutine that batch-writes Entries to
+// cfg.FilePath, reopening it on SIGUSR1 so external log rotation
+// (logrotate's copytruncate or rename+create) doesn't leave it writing to a
+// deleted inode.
+type Writer struct {
+	cfg     Config
+	entries chan *Entry
+	metrics *Metrics
+
+	mu   sync.Mutex
+	file *os.File
+	buf  *bufio.Writer
+
+	sigCh chan os.Signal
+	done  chan struct{}
+}
+
+// NewWriter opens cfg.FilePath and starts the background writer goroutine.
+func NewWriter(cfg Config) (*Writer, error) {
+	if cfg.Format == "" {
+		cfg.Format = Common
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 1000
+	}
+
+	w := &Writer{
+		cfg:     cfg,
+		entries: make(chan *Entry, cfg.BufferSize),
+		sigCh:   make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+	}
+	if err := w.reopen(); err != nil {
+		return nil, err
+	}
+
+	signal.Notify(w.sigCh, syscall.SIGUSR1)
+	go w.run()
+	return w, nil
+}
+
+// EnableMetrics attaches metrics so entries dropped for a full buffer
+// increment lb_access_log_dropped_total.
+func (w *Writer) EnableMetrics(metrics *Metrics) {
+	w.metrics = metrics
+}
+
+// Enqueue queues e for the background writer, or drops it if the buffer is
+// full rather than blocking the request path.
+func (w *Writer) Enqueue(e *Entry) {
+	select {
+	case w.entries <- e:
+	default:
+		if w.metrics != nil {
+			w.metrics.droppedTotal.Inc()
+		}
+	}
+}
+
+// shouldLog reports whether r should be skipped per cfg.Filters.
+func (w *Writer) shouldLog(r *http.Request) bool {
+	for _, filter := range w.cfg.Filters {
+		if filter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Close stops the background writer, flushing any buffered entries first.
+func (w *Writer) Close() error {
+	close(w.done)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf != nil {
+	drain:
+		for {
+			select {
+			case e := <-w.entries:
+				w.writeLocked(e)
+			default:
+				break drain
+			}
+		}
+		w.buf.Flush()
+	}
+	if w.file != nil {
+		return w.file.Close()
+	}
+	return nil
+}
+
+func (w *Writer) reopen() error {
+	f, err := os.OpenFile(w.cfg.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("accesslog: failed to open %s: %w", w.cfg.FilePath, err)
+	}
+
+	w.mu.Lock()
+	old := w.file
+	w.file = f
+	w.buf = bufio.NewWriter(f)
+	w.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// run batch-writes queued entries until Close, draining whatever's
+// currently queued before each flush so a burst of requests doesn't fsync
+// once per line.
+func (w *Writer) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.sigCh:
+			if err := w.reopen(); err != nil {
+				fmt.Fprintf(os.Stderr, "accesslog: reopen on SIGUSR1 failed: %v\n", err)
+			}
+		case e := <-w.entries:
+			w.writeBatch(e)
+		}
+	}
+}
+
+func (w *Writer) writeBatch(first *Entry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writeLocked(first)
+drain:
+	for {
+		select {
+		case e := <-w.entries:
+			w.writeLocked(e)
+		default:
+			break drain
+		}
+	}
+	w.buf.Flush()
+}
+
+func (w *Writer) writeLocked(e *Entry) {
+	if w.cfg.Format == JSON {
+		w.buf.WriteString(renderJSON(e))
+	} else {
+		w.buf.WriteString(renderCommon(e))
+	}
+	w.buf.WriteByte('\n')
+}
+
+// statusRecorder captures the status code and byte count Middleware needs
+// after next.ServeHTTP returns.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	n, err := sr.ResponseWriter.Write(b)
+	sr.bytes += int64(n)
+	return n, err
+}
+
+// Middleware records one Entry per request not skipped by w's Filters,
+// enqueuing it to w once the response completes. Downstream handlers can
+// attach backend-specific fields via FromContext(r.Context()) before they
+// return.
+func Middleware(next http.Handler, w *Writer) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !w.shouldLog(r) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		entry := &Entry{
+			Timestamp: time.Now(),
+			ClientIP:  clientIP(r),
+			Method:    r.Method,
+			URI:       r.RequestURI,
+			Protocol:  r.Proto,
+			UserAgent: r.UserAgent(),
+			Referer:   r.Referer(),
+		}
+		rec := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(NewContext(r.Context(), entry)))
+		entry.DownstreamDuration = time.Since(start)
+		entry.Status = rec.status
+		entry.BytesSent = rec.bytes
+
+		w.Enqueue(entry)
+	})
+}
+
+// clientIP returns the first hop in X-Forwarded-For if present, else the
+// connection's remote address.
+func clientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		if len(parts) > 0 {
+			return strings.TrimSpace(parts[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// renderCommon renders e in Apache Combined Log Format, with this
+// package's extra upstream/session/region/circuit/policy fields appended as
+// trailing key=value pairs rather than breaking CLF's fixed field order.
+func renderCommon(e *Entry) string {
+	return fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %d "%s" "%s" backend=%s upstream_ms=%d downstream_ms=%d session=%s region=%s circuit=%s policy=%s`,
+		orDash(e.ClientIP),
+		e.Timestamp.Format("02/Jan/2006:15:04:05 -0700"),
+		orDash(e.Method), orDash(e.URI), orDash(e.Protocol),
+		e.Status, e.BytesSent,
+		orDash(e.Referer), orDash(e.UserAgent),
+		orDash(e.Backend), e.UpstreamDuration.Milliseconds(), e.DownstreamDuration.Milliseconds(),
+		orDash(e.SessionID), orDash(e.Region), orDash(e.CircuitBreakerState), orDash(e.PolicyName),
+	)
+}
+
+func renderJSON(e *Entry) string {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf(`{"error":"accesslog: failed to marshal entry: %s"}`, err)
+	}
+	return string(b)
+}
@@ -1,62 +1,456 @@
 package loadbalancer
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log"
-	"sync/atomic"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 )
 
-// AutoScaler monitors request load and automatically scales backend servers
+// BackendProvisioner starts and stops backend instances for AutoScaler.
+// Provision returns the URL of a newly started backend; Deprovision tears
+// one down given a URL a prior Provision call returned. Implementations
+// decide what "an instance" means (an in-process mock server, a container, a
+// Consul-registered service) - AutoScaler only ever deals in URLs.
+type BackendProvisioner interface {
+	Provision(ctx context.Context) (backendURL string, err error)
+	Deprovision(ctx context.Context, backendURL string) error
+}
+
+// MockServerProvisioner provisions in-process mock backend servers (see
+// StartMockServer), for local development and for demoing autoscaling
+// without any real infrastructure behind it. Ports are handed out
+// sequentially starting at basePort.
+type MockServerProvisioner struct {
+	mu       sync.Mutex
+	nextPort int
+	servers  map[string]*http.Server
+}
+
+// NewMockServerProvisioner returns a MockServerProvisioner handing out ports
+// starting at basePort.
+func NewMockServerProvisioner(basePort int) *MockServerProvisioner {
+	return &MockServerProvisioner{
+		nextPort: basePort,
+		servers:  make(map[string]*http.Server),
+	}
+}
+
+// Provision starts a new mock backend server on the next free port and
+// returns its URL.
+func (p *MockServerProvisioner) Provision(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	port := p.nextPort
+	p.nextPort++
+	p.mu.Unlock()
+
+	portStr := strconv.Itoa(port)
+	srv := NewMockBackendServer(portStr)
+
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return "", fmt.Errorf("mock provisioner: backend on port %s failed to start: %w", portStr, err)
+	case <-time.After(100 * time.Millisecond):
+		// Gave the listener a moment to fail fast on a bound-port error;
+		// otherwise assume it came up.
+	}
+
+	backendURL := fmt.Sprintf("http://localhost:%s", portStr)
+
+	p.mu.Lock()
+	p.servers[backendURL] = srv
+	p.mu.Unlock()
+
+	log.Printf("[INFO] MockServerProvisioner: provisioned backend %s", backendURL)
+	return backendURL, nil
+}
+
+// Deprovision shuts down the mock backend server previously returned by
+// Provision at backendURL.
+func (p *MockServerProvisioner) Deprovision(ctx context.Context, backendURL string) error {
+	p.mu.Lock()
+	srv, ok := p.servers[backendURL]
+	delete(p.servers, backendURL)
+	p.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("mock provisioner: no backend tracked for %s", backendURL)
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("mock provisioner: failed to shut down %s: %w", backendURL, err)
+	}
+	log.Printf("[INFO] MockServerProvisioner: deprovisioned backend %s", backendURL)
+	return nil
+}
+
+// DockerBackendProvisioner provisions backend containers via the Docker
+// Engine API. Not implemented in this environment (no Docker client is
+// vendored here) - Provision/Deprovision fail loudly rather than silently
+// no-op, so a misconfigured deployment doesn't look like it's autoscaling
+// when it isn't.
+type DockerBackendProvisioner struct {
+	Image   string
+	Network string
+}
+
+// NewDockerBackendProvisioner returns a DockerBackendProvisioner that would
+// start containers from image on network.
+func NewDockerBackendProvisioner(image, network string) *DockerBackendProvisioner {
+	return &DockerBackendProvisioner{Image: image, Network: network}
+}
+
+func (p *DockerBackendProvisioner) Provision(ctx context.Context) (string, error) {
+	return "", fmt.Errorf("docker backend provisioning is not implemented yet")
+}
+
+func (p *DockerBackendProvisioner) Deprovision(ctx context.Context, backendURL string) error {
+	return fmt.Errorf("docker backend provisioning is not implemented yet")
+}
+
+// ConsulAgentBackendProvisioner provisions a local instance through an
+// underlying BackendProvisioner (typically a MockServerProvisioner, or
+// whatever actually starts the process), then registers it with the Consul
+// agent at agentBaseURL under serviceName so ConsulServiceManager picks it up
+// on its next watch tick. Deprovision deregisters it from Consul before
+// tearing the instance down.
+type ConsulAgentBackendProvisioner struct {
+	agentBaseURL string
+	serviceName  string
+	token        string
+	client       *http.Client
+	underlying   BackendProvisioner
+}
+
+// NewConsulAgentBackendProvisioner returns a ConsulAgentBackendProvisioner
+// registering instances from underlying as serviceName with the Consul agent
+// at agentBaseURL (e.g. "http://localhost:8500").
+func NewConsulAgentBackendProvisioner(agentBaseURL, serviceName string, underlying BackendProvisioner) *ConsulAgentBackendProvisioner {
+	return &ConsulAgentBackendProvisioner{
+		agentBaseURL: agentBaseURL,
+		serviceName:  serviceName,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		underlying:   underlying,
+	}
+}
+
+// WithConsulAgentToken sets the ACL token sent as X-Consul-Token on every
+// agent registration/deregistration call.
+func (p *ConsulAgentBackendProvisioner) WithConsulAgentToken(token string) *ConsulAgentBackendProvisioner {
+	p.token = token
+	return p
+}
+
+func (p *ConsulAgentBackendProvisioner) Provision(ctx context.Context) (string, error) {
+	backendURL, err := p.underlying.Provision(ctx)
+	if err != nil {
+		return "", fmt.Errorf("consul-agent provisioner: underlying provision failed: %w", err)
+	}
+
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		return "", fmt.Errorf("consul-agent provisioner: failed to parse backend URL %s: %w", backendURL, err)
+	}
+	port, _ := strconv.Atoi(u.Port())
+
+	registration := map[string]interface{}{
+		"ID":      p.serviceID(u.Port()),
+		"Name":    p.serviceName,
+		"Address": u.Hostname(),
+		"Port":    port,
+	}
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return "", fmt.Errorf("consul-agent provisioner: failed to marshal registration for %s: %w", backendURL, err)
+	}
+
+	if err := p.agentRequest(ctx, http.MethodPut, "/v1/agent/service/register", body); err != nil {
+		if derr := p.underlying.Deprovision(ctx, backendURL); derr != nil {
+			log.Printf("[ERROR] consul-agent provisioner: failed to roll back %s after registration failure: %v", backendURL, derr)
+		}
+		return "", err
+	}
+
+	log.Printf("[INFO] ConsulAgentBackendProvisioner: registered %s as service %s", backendURL, p.serviceName)
+	return backendURL, nil
+}
+
+func (p *ConsulAgentBackendProvisioner) Deprovision(ctx context.Context, backendURL string) error {
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		return fmt.Errorf("consul-agent provisioner: failed to parse backend URL %s: %w", backendURL, err)
+	}
+
+	if err := p.agentRequest(ctx, http.MethodPut, "/v1/agent/service/deregister/"+p.serviceID(u.Port()), nil); err != nil {
+		return err
+	}
+
+	if err := p.underlying.Deprovision(ctx, backendURL); err != nil {
+		return fmt.Errorf("consul-agent provisioner: deregistered %s from Consul but underlying teardown failed: %w", backendURL, err)
+	}
+
+	log.Printf("[INFO] ConsulAgentBackendProvisioner: deregistered %s", backendURL)
+	return nil
+}
+
+func (p *ConsulAgentBackendProvisioner) serviceID(port string) string {
+	return fmt.Sprintf("%s-%s", p.serviceName, port)
+}
+
+func (p *ConsulAgentBackendProvisioner) agentRequest(ctx context.Context, method, path string, body []byte) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.agentBaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("consul-agent provisioner: failed to build request for %s: %w", path, err)
+	}
+	if p.token != "" {
+		req.Header.Set("X-Consul-Token", p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("consul-agent provisioner: request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("consul-agent provisioner: %s returned %d: %s", path, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// autoScalerCheckInterval is how often AutoScaler samples its EWMA load
+// signal and re-evaluates the scale-up/scale-down decision.
+const autoScalerCheckInterval = 15 * time.Second
+
+// latencyWindowSize bounds how many recent request latencies AutoScaler
+// keeps between ticks to estimate p95 latency.
+const latencyWindowSize = 200
+
+// rpsAlpha and p95Alpha smooth the per-tick RPS/p95 samples into an EWMA,
+// the same inline-EWMA idiom Backend.RecordMetrics uses for latency/success.
+const (
+	rpsAlpha = 0.3
+	p95Alpha = 0.3
+)
+
+// AutoScaler watches an EWMA-smoothed requests-per-second and p95-latency
+// signal, fed by RecordRequest from the proxy handler, and provisions or
+// deprovisions backend instances through a BackendProvisioner. The
+// scale-up/scale-down decision itself (dual-threshold with cooldown, leader-
+// fenced for multi-replica deployments) is delegated to ServerPool's
+// existing AutoScalingStateManager via TryScale; AutoScaler's job is turning
+// "should scale" into an actual new or removed backend.
 type AutoScaler struct {
-	RequestCount *int64
-	ServerPool   ServerPoolInterface // Use the interface
-	Threshold    int
+	ServerPool   ServerPoolInterface
+	Provisioner  BackendProvisioner
+	Threshold    int64
+	MinInstances int
+	MaxInstances int
+
+	mu           sync.Mutex
+	requestCount int64
+	latencies    []time.Duration
+	lastTick     time.Time
+	rpsEWMA      float64
+	p95EWMA      float64
 }
 
-// NewAutoScaler creates a new autoscaler instance
-func NewAutoScaler(requestCount *int64, threshold int) *AutoScaler {
+// NewAutoScaler creates an AutoScaler that provisions/deprovisions backends
+// through provisioner, staying within [minInstances, maxInstances] and
+// treating threshold as the requests-per-check-interval trigger point (see
+// AutoScalingStateManager.ShouldScale for the exact dual-threshold rule).
+func NewAutoScaler(serverPool ServerPoolInterface, provisioner BackendProvisioner, threshold int64, minInstances, maxInstances int) *AutoScaler {
 	return &AutoScaler{
-		RequestCount: requestCount,
+		ServerPool:   serverPool,
+		Provisioner:  provisioner,
 		Threshold:    threshold,
+		MinInstances: minInstances,
+		MaxInstances: maxInstances,
+		lastTick:     time.Now(),
 	}
 }
 
-// Start begins the autoscaling monitoring loop
+// RecordRequest feeds one completed request's latency into the load signal.
+// Call this from the proxy handler after each request completes.
+func (as *AutoScaler) RecordRequest(latency time.Duration) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.requestCount++
+	as.latencies = append(as.latencies, latency)
+	if len(as.latencies) > latencyWindowSize {
+		as.latencies = as.latencies[len(as.latencies)-latencyWindowSize:]
+	}
+}
+
+// Start begins the autoscaling monitoring loop; it blocks, so callers should
+// run it in a goroutine (see AutoScalerLoop).
 func (as *AutoScaler) Start() {
-	t := time.NewTicker(15 * time.Second)
+	t := time.NewTicker(autoScalerCheckInterval)
 	defer t.Stop()
 
-	for {
-		select {
-		case <-t.C:
-			as.checkAndScale()
+	for range t.C {
+		as.checkAndScale()
+	}
+}
+
+// sample snapshots and resets the per-tick counters, folds them into the
+// RPS/p95 EWMAs, and returns the smoothed values for this tick.
+func (as *AutoScaler) sample() (rps, p95Ms float64) {
+	as.mu.Lock()
+	count := as.requestCount
+	elapsed := time.Since(as.lastTick).Seconds()
+	latencies := as.latencies
+	as.requestCount = 0
+	as.latencies = nil
+	as.lastTick = time.Now()
+
+	if elapsed <= 0 {
+		elapsed = autoScalerCheckInterval.Seconds()
+	}
+	rpsSample := float64(count) / elapsed
+	if as.rpsEWMA == 0 {
+		as.rpsEWMA = rpsSample
+	} else {
+		as.rpsEWMA = rpsAlpha*rpsSample + (1-rpsAlpha)*as.rpsEWMA
+	}
+
+	if len(latencies) > 0 {
+		sorted := make([]time.Duration, len(latencies))
+		copy(sorted, latencies)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		idx := int(float64(len(sorted)) * 0.95)
+		if idx >= len(sorted) {
+			idx = len(sorted) - 1
+		}
+		p95Sample := sorted[idx].Seconds() * 1000
+		if as.p95EWMA == 0 {
+			as.p95EWMA = p95Sample
+		} else {
+			as.p95EWMA = p95Alpha*p95Sample + (1-p95Alpha)*as.p95EWMA
 		}
 	}
+
+	rps, p95Ms = as.rpsEWMA, as.p95EWMA
+	as.mu.Unlock()
+	return rps, p95Ms
 }
 
-// checkAndScale monitors load and adds new backends if needed
 func (as *AutoScaler) checkAndScale() {
-	count := atomic.SwapInt64(as.RequestCount, 0)
+	ctx := context.Background()
+	rps, p95Ms := as.sample()
+
+	// ShouldScale's dual-threshold/cooldown logic is expressed in terms of a
+	// request count against Threshold, so project the smoothed RPS back onto
+	// the same per-interval scale it was tuned for.
+	projectedCount := int64(rps * autoScalerCheckInterval.Seconds())
+
+	scaleUp, scaleDown, err := as.ServerPool.TryScale(ctx, projectedCount, as.Threshold)
+	if err != nil {
+		log.Printf("[ERROR] AutoScaler: scale decision failed (rps=%.2f p95_ms=%.1f): %v", rps, p95Ms, err)
+		return
+	}
+
+	current := as.ServerPool.BackendCount()
+	switch {
+	case scaleUp:
+		if current >= as.MaxInstances {
+			log.Printf("[INFO] AutoScaler: scale-up signaled (rps=%.2f p95_ms=%.1f threshold=%d instances=%d) but already at max instances (%d)", rps, p95Ms, as.Threshold, current, as.MaxInstances)
+			return
+		}
+		as.scaleUp(ctx, rps, p95Ms)
+	case scaleDown:
+		if current <= as.MinInstances {
+			log.Printf("[INFO] AutoScaler: scale-down signaled (rps=%.2f p95_ms=%.1f threshold=%d instances=%d) but already at min instances (%d)", rps, p95Ms, as.Threshold, current, as.MinInstances)
+			return
+		}
+		as.scaleDown(ctx, rps, p95Ms)
+	default:
+		log.Printf("[INFO] AutoScaler: no scaling action (rps=%.2f p95_ms=%.1f threshold=%d instances=%d)", rps, p95Ms, as.Threshold, current)
+	}
+}
 
-	// If request count is high, add a new backend server
-	if count > int64(as.Threshold) {
-		log.Printf("[INFO] High load detected: %d requests, triggering autoscaling", count)
-		as.addNewBackend()
+func (as *AutoScaler) scaleUp(ctx context.Context, rps, p95Ms float64) {
+	backendURL, err := as.Provisioner.Provision(ctx)
+	if err != nil {
+		log.Printf("[ERROR] AutoScaler: scale-up provisioning failed (rps=%.2f p95_ms=%.1f): %v", rps, p95Ms, err)
+		return
+	}
+	if err := as.ServerPool.AddProvisionedBackend(backendURL); err != nil {
+		log.Printf("[ERROR] AutoScaler: failed to register provisioned backend %s: %v", backendURL, err)
+		if derr := as.Provisioner.Deprovision(ctx, backendURL); derr != nil {
+			log.Printf("[ERROR] AutoScaler: failed to roll back provisioning of %s: %v", backendURL, derr)
+		}
+		return
 	}
+	log.Printf("[INFO] AutoScaler: scaled up, added backend %s (rps=%.2f p95_ms=%.1f)", backendURL, rps, p95Ms)
 }
 
-// addNewBackend adds a new backend server to the pool
-func (as *AutoScaler) addNewBackend() {
-	// This would need to be implemented with proper ServerPool interface
-	// For now, this is a placeholder showing the structure
-	log.Printf("[INFO] AutoScaler: Would add new backend server")
-	// TODO: Implement actual backend addition logic with StartMockServer from server package
+func (as *AutoScaler) scaleDown(ctx context.Context, rps, p95Ms float64) {
+	backendURL, ok := as.ServerPool.LastProvisionedBackend()
+	if !ok {
+		log.Printf("[INFO] AutoScaler: scale-down signaled but no autoscaler-provisioned backend left to remove (rps=%.2f p95_ms=%.1f)", rps, p95Ms)
+		return
+	}
+	if !as.ServerPool.RemoveProvisionedBackend(ctx, backendURL) {
+		log.Printf("[WARN] AutoScaler: scale-down could not remove backend %s (already gone?)", backendURL)
+		return
+	}
+	if err := as.Provisioner.Deprovision(ctx, backendURL); err != nil {
+		log.Printf("[ERROR] AutoScaler: failed to deprovision backend %s: %v", backendURL, err)
+		return
+	}
+	log.Printf("[INFO] AutoScaler: scaled down, removed backend %s (rps=%.2f p95_ms=%.1f)", backendURL, rps, p95Ms)
 }
 
-// AutoScalerLoop monitors request load and automatically scales backend servers
-// This runs as a background goroutine in main.go
-func AutoScalerLoop(requestCount *int64, serverPool ServerPoolInterface) {
-	as := NewAutoScaler(requestCount, 20) // threshold of 20
-	as.ServerPool = serverPool
+// AutoScalerStatus is a snapshot of AutoScaler's current signal and
+// configuration, for the /autoscaler/status endpoint.
+type AutoScalerStatus struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	P95LatencyMs      float64 `json:"p95_latency_ms"`
+	Threshold         int64   `json:"threshold"`
+	MinInstances      int     `json:"min_instances"`
+	MaxInstances      int     `json:"max_instances"`
+	CurrentInstances  int     `json:"current_instances"`
+}
+
+// Status returns a snapshot of as's current signal and configuration.
+func (as *AutoScaler) Status() AutoScalerStatus {
+	as.mu.Lock()
+	rps, p95Ms := as.rpsEWMA, as.p95EWMA
+	as.mu.Unlock()
+
+	return AutoScalerStatus{
+		RequestsPerSecond: rps,
+		P95LatencyMs:      p95Ms,
+		Threshold:         as.Threshold,
+		MinInstances:      as.MinInstances,
+		MaxInstances:      as.MaxInstances,
+		CurrentInstances:  as.ServerPool.BackendCount(),
+	}
+}
+
+// AutoScalerLoop runs as's monitoring loop. This is the entry point called
+// as a background goroutine from main.go.
+func AutoScalerLoop(as *AutoScaler) {
 	as.Start()
-}
\ No newline at end of file
+}
@@ -0,0 +1,152 @@
+package loadbalancer
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// BreakerRegistry owns one Tracker per backend URL so callers -- proxy
+// handlers, the SetBackendState path, background health checks -- can share
+// breaker state for a backend instead of each holding their own. It exposes
+// Prometheus gauges for state and request counts alongside the trackers.
+type BreakerRegistry struct {
+	mu       sync.RWMutex
+	trackers map[string]*Tracker
+	newOpts  func(backendURL string) []CircuitBreakerOption
+
+	stateGauge  *prometheus.GaugeVec
+	countsGauge *prometheus.GaugeVec
+
+	// bus and busCtx are set by EnableClusterPublish, in which case every
+	// state transition is also published to bus so peers can mirror it.
+	bus    *ClusterBus
+	busCtx context.Context
+}
+
+// NewBreakerRegistry creates a BreakerRegistry. newOpts builds the
+// CircuitBreakerOption set for a given backend URL when a Tracker for it is
+// first requested (pass a func returning nil for all-default trackers). The
+// registry registers its own collectors against reg; pass the same registry
+// used for the rest of the load balancer's Prometheus metrics.
+func NewBreakerRegistry(reg *prometheus.Registry, newOpts func(backendURL string) []CircuitBreakerOption) *BreakerRegistry {
+	if newOpts == nil {
+		newOpts = func(string) []CircuitBreakerOption { return nil }
+	}
+
+	br := &BreakerRegistry{
+		trackers: make(map[string]*Tracker),
+		newOpts:  newOpts,
+		stateGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "loadbalancer_circuit_breaker_state",
+				Help: "Circuit breaker state per backend (0=closed, 1=open, 2=half-open)",
+			},
+			[]string{"backend"},
+		),
+		countsGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "loadbalancer_circuit_breaker_consecutive_failures",
+				Help: "Consecutive failures recorded by the circuit breaker per backend",
+			},
+			[]string{"backend"},
+		),
+	}
+
+	if reg != nil {
+		reg.MustRegister(br.stateGauge)
+		reg.MustRegister(br.countsGauge)
+	}
+
+	return br
+}
+
+// EnableClusterPublish makes the registry publish every circuit breaker state
+// transition via bus, so a trip on this replica opens the breaker on peers
+// immediately instead of each replica learning it the hard way. Call before
+// any Get(), since trackers wire their onStateChange callback at creation
+// time and won't pick up a bus enabled afterward.
+func (br *BreakerRegistry) EnableClusterPublish(ctx context.Context, bus *ClusterBus) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.bus = bus
+	br.busCtx = ctx
+}
+
+// Get returns the Tracker for backendURL, creating one on first use.
+func (br *BreakerRegistry) Get(backendURL string) *Tracker {
+	br.mu.RLock()
+	tracker, ok := br.trackers[backendURL]
+	br.mu.RUnlock()
+	if ok {
+		return tracker
+	}
+
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	if tracker, ok = br.trackers[backendURL]; ok {
+		return tracker
+	}
+
+	bus, busCtx := br.bus, br.busCtx
+	opts := append(br.newOpts(backendURL), WithOnStateChange(func(name string, from, to CircuitBreakerState) {
+		br.stateGauge.WithLabelValues(name).Set(float64(to))
+		if bus != nil {
+			if err := bus.PublishBreakerState(busCtx, BreakerStateEvent{BackendURL: name, State: to}); err != nil {
+				log.Printf("[WARN] BreakerRegistry: failed to publish state change for %s: %v", name, err)
+			}
+		}
+	}))
+	tracker = NewTracker(backendURL, opts...)
+	br.trackers[backendURL] = tracker
+	return tracker
+}
+
+// State returns the current state of the tracker for backendURL without
+// creating one if it doesn't exist yet.
+func (br *BreakerRegistry) State(backendURL string) CircuitBreakerState {
+	br.mu.RLock()
+	tracker, ok := br.trackers[backendURL]
+	br.mu.RUnlock()
+	if !ok {
+		return StateClosed
+	}
+	return tracker.State()
+}
+
+// Remove drops the tracker for backendURL, e.g. once a backend is deprovisioned.
+func (br *BreakerRegistry) Remove(backendURL string) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	delete(br.trackers, backendURL)
+}
+
+// SnapshotMetrics refreshes the consecutive-failures gauge for every tracked
+// backend. Call it periodically (e.g. from the health-check loop) since,
+// unlike state transitions, failure counts aren't pushed on every change.
+func (br *BreakerRegistry) SnapshotMetrics() {
+	br.mu.RLock()
+	defer br.mu.RUnlock()
+	for backendURL, tracker := range br.trackers {
+		counts := tracker.Counts()
+		br.countsGauge.WithLabelValues(backendURL).Set(float64(counts.ConsecutiveFailures))
+	}
+}
+
+// SlidingWindowReadyToTrip returns a readyToTrip strategy that trips once at
+// least minRequests have been observed in the current generation and the
+// fraction of failures within it meets or exceeds failureRatio. It's an
+// alternative to the default consecutive-failures rule, useful for backends
+// that fail intermittently rather than in a row; pair it with
+// WithInterval(window) so the generation -- and therefore the sliding window
+// -- rolls over every window.
+func SlidingWindowReadyToTrip(minRequests uint32, failureRatio float64) func(counts Counts) bool {
+	return func(counts Counts) bool {
+		if counts.Requests < minRequests {
+			return false
+		}
+		return float64(counts.TotalFailures)/float64(counts.Requests) >= failureRatio
+	}
+}
@@ -15,20 +15,18 @@ const (
 	StateHalfOpen
 )
 
-// CircuitBreaker implements the circuit breaker pattern for fault tolerance
-// Prevents cascading failures by stopping calls to unhealthy services
-type CircuitBreaker struct {
-	name           string
-	maxRequests    uint32
- interval        time.Duration
-	timeout        time.Duration
-	readyToTrip    func(counts Counts) bool
-	onStateChange  func(name string, from CircuitBreakerState, to CircuitBreakerState)
-	mutex          sync.RWMutex
-	state          CircuitBreakerState
-	generation     uint64
-	counts         Counts
-	expiry         time.Time
+// String renders a CircuitBreakerState for log fields and dashboards.
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
 }
 
 // Counts holds the statistics of the circuit breaker
@@ -40,60 +38,56 @@ type Counts struct {
 	ConsecutiveFailures  uint32
 }
 
-// CircuitBreakerOption configures CircuitBreaker
-type CircuitBreakerOption func(*CircuitBreaker)
-
-// WithMaxRequests sets the maximum number of requests allowed when the circuit breaker is half-open
-func WithMaxRequests(maxRequests uint32) CircuitBreakerOption {
-	return func(cb *CircuitBreaker) {
-		cb.maxRequests = maxRequests
-	}
-}
-
-// WithInterval sets the cyclic period of the closed state for the circuit breaker to clear statistics
-func WithInterval(interval time.Duration) CircuitBreakerOption {
-	return func(cb *CircuitBreaker) {
-		cb.interval = interval
-	}
+// Successes returns the number of successful requests in the current generation
+func (c Counts) Successes() uint32 {
+	return c.Requests - c.ConsecutiveFailures
 }
 
-// WithTimeout sets the timeout of the open state for the circuit breaker
-func WithTimeout(timeout time.Duration) CircuitBreakerOption {
-	return func(cb *CircuitBreaker) {
-		cb.timeout = timeout
-	}
+// Failures returns the number of failed requests in the current generation
+func (c Counts) Failures() uint32 {
+	return c.ConsecutiveFailures
 }
 
-// WithReadyToTrip sets the criteria for tripping the circuit breaker
-func WithReadyToTrip(readyToTrip func(counts Counts) bool) CircuitBreakerOption {
-	return func(cb *CircuitBreaker) {
-		cb.readyToTrip = readyToTrip
-	}
+// Tracker holds the counting/state-machine logic that used to live directly on
+// CircuitBreaker. It has no opinion on how callers invoke the guarded code --
+// CircuitBreaker.Execute is one way -- so it can be embedded in custom flows
+// (wrapping a Redis call or a gRPC client, say) without inheriting the Execute
+// contract.
+type Tracker struct {
+	name          string
+	maxRequests   uint32
+	interval      time.Duration
+	timeout       time.Duration
+	readyToTrip   func(counts Counts) bool
+	onStateChange func(name string, from CircuitBreakerState, to CircuitBreakerState)
+	logger        Logger
+
+	mutex      sync.RWMutex
+	state      CircuitBreakerState
+	generation uint64
+	counts     Counts
+	expiry     time.Time
 }
 
-// WithOnStateChange sets the callback function to be called when the circuit breaker state changes
-func WithOnStateChange(onStateChange func(name string, from CircuitBreakerState, to CircuitBreakerState)) CircuitBreakerOption {
-	return func(cb *CircuitBreaker) {
-		cb.onStateChange = onStateChange
+// NewTracker creates a Tracker with the given name and options.
+func NewTracker(name string, opts ...CircuitBreakerOption) *Tracker {
+	cb := newCircuitBreakerState(name)
+	for _, opt := range opts {
+		opt(cb)
 	}
+	return cb
 }
 
-// NewCircuitBreaker creates a new CircuitBreaker with the given name and options
-func NewCircuitBreaker(name string, opts ...CircuitBreakerOption) *CircuitBreaker {
-	cb := &CircuitBreaker{
+func newCircuitBreakerState(name string) *Tracker {
+	return &Tracker{
 		name:        name,
 		maxRequests: 1,
 		interval:    60 * time.Second,
 		timeout:     60 * time.Second,
 		readyToTrip: defaultReadyToTrip,
 		state:       StateClosed,
+		logger:      NewNoopLogger(),
 	}
-
-	for _, opt := range opts {
-		opt(cb)
-	}
-
-	return cb
 }
 
 // defaultReadyToTrip uses the default criteria for tripping the circuit breaker
@@ -101,179 +95,255 @@ func defaultReadyToTrip(counts Counts) bool {
 	return counts.ConsecutiveFailures > 5
 }
 
-// Execute runs the given function if the circuit breaker is available
-// It returns an error if the circuit breaker is open or the function fails
-func (cb *CircuitBreaker) Execute(req func() (any, error)) (any, error) {
-	generation, err := cb.beforeRequest()
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		e := recover()
-		if e != nil {
-			cb.afterRequest(generation, false)
-			panic(e)
-		}
-	}()
-
-	result, err := req()
-	cb.afterRequest(generation, err == nil)
-	return result, err
-}
-
-// beforeRequest determines whether the circuit breaker allows the request
-func (cb *CircuitBreaker) beforeRequest() (uint64, error) {
-	cb.mutex.Lock()
-
-	defer cb.mutex.Unlock()
+// OnRequest determines whether the tracker allows a new request and, if so,
+// returns the generation it was admitted under. Callers pass that generation
+// back to OnSuccess/OnFailure so a result from a generation that has since
+// rolled over is ignored.
+func (t *Tracker) OnRequest() (uint64, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 
 	now := time.Now()
-	state, generation := cb.currentState(now)
+	state, generation := t.currentState(now)
 
 	switch state {
 	case StateOpen:
 		return generation, ErrTooManyRequests
 	case StateHalfOpen:
-		if cb.counts.Requests >= cb.maxRequests {
+		if t.counts.Requests >= t.maxRequests {
 			return generation, ErrTooManyRequests
 		}
 	}
 
-	cb.counts.Requests++
+	t.counts.Requests++
 	return generation, nil
 }
 
-// afterRequest processes the result of a request
-func (cb *CircuitBreaker) afterRequest(before uint64, success bool) {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+// OnSuccess records a successful request admitted under generation.
+func (t *Tracker) OnSuccess(generation uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 
 	now := time.Now()
-	state, generation := cb.currentState(now)
-	if generation != before {
+	state, current := t.currentState(now)
+	if current != generation {
 		return
 	}
 
-	if success {
-		cb.onSuccess(state, now)
-	} else {
-		cb.onFailure(state, now)
-	}
-}
-
-// onSuccess processes a successful request
-func (cb *CircuitBreaker) onSuccess(state CircuitBreakerState, now time.Time) {
-	cb.counts.TotalSuccesses++
-	cb.counts.ConsecutiveSuccesses++
-	cb.counts.ConsecutiveFailures = 0
+	t.counts.TotalSuccesses++
+	t.counts.ConsecutiveSuccesses++
+	t.counts.ConsecutiveFailures = 0
 
 	switch state {
 	case StateClosed:
 		// Do nothing, stay in closed state
 	case StateHalfOpen:
-		if cb.counts.Successes() >= cb.maxRequests {
-			cb.setState(StateClosed, now)
+		if t.counts.Successes() >= t.maxRequests {
+			t.setState(StateClosed, now)
 		}
 	}
 }
 
-// onFailure processes a failed request
-func (cb *CircuitBreaker) onFailure(state CircuitBreakerState, now time.Time) {
-	cb.counts.TotalFailures++
-	cb.counts.ConsecutiveFailures++
-	cb.counts.ConsecutiveSuccesses = 0
+// OnFailure records a failed request admitted under generation.
+func (t *Tracker) OnFailure(generation uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	state, current := t.currentState(now)
+	if current != generation {
+		return
+	}
+
+	t.counts.TotalFailures++
+	t.counts.ConsecutiveFailures++
+	t.counts.ConsecutiveSuccesses = 0
 
 	switch state {
 	case StateClosed:
-		if cb.readyToTrip(cb.counts) {
-			cb.setState(StateOpen, now)
+		if t.readyToTrip(t.counts) {
+			t.setState(StateOpen, now)
 		}
 	case StateHalfOpen:
-		cb.setState(StateOpen, now)
+		t.setState(StateOpen, now)
 	}
 }
 
-// currentState returns the current state of the circuit breaker
-func (cb *CircuitBreaker) currentState(now time.Time) (CircuitBreakerState, uint64) {
-	switch cb.state {
+// CurrentState returns the tracker's state as of now, advancing it through any
+// expired closed/open window first.
+func (t *Tracker) CurrentState(now time.Time) CircuitBreakerState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	state, _ := t.currentState(now)
+	return state
+}
+
+// Counts returns the internal counts of the current generation.
+func (t *Tracker) Counts() Counts {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.counts
+}
+
+// currentState returns the current state and generation, transitioning out of
+// an expired closed interval or a timed-out open state. Callers must hold mutex.
+func (t *Tracker) currentState(now time.Time) (CircuitBreakerState, uint64) {
+	switch t.state {
 	case StateClosed:
-		if !cb.expiry.IsZero() && cb.expiry.Before(now) {
-			cb.toNewGeneration(now)
+		if !t.expiry.IsZero() && t.expiry.Before(now) {
+			t.toNewGeneration(now)
 		}
 	case StateOpen:
-		if cb.expiry.Before(now) {
-			cb.setState(StateHalfOpen, now)
+		if t.expiry.Before(now) {
+			t.setState(StateHalfOpen, now)
 		}
 	}
-	return cb.state, cb.generation
+	return t.state, t.generation
 }
 
-// setState sets the state of the circuit breaker and calls the onStateChange callback
-func (cb *CircuitBreaker) setState(state CircuitBreakerState, now time.Time) {
-	if cb.state == state {
+// setState sets the state of the tracker and calls the onStateChange callback.
+// Callers must hold mutex.
+func (t *Tracker) setState(state CircuitBreakerState, now time.Time) {
+	if t.state == state {
 		return
 	}
 
-	prev := cb.state
-	cb.state = state
+	prev := t.state
+	t.state = state
 
-	cb.toNewGeneration(now)
+	t.toNewGeneration(now)
+
+	if t.logger != nil {
+		t.logger.Info("circuit breaker state changed",
+			String("circuit_state", state.String()),
+			String("previous_state", prev.String()),
+		)
+	}
 
-	if cb.onStateChange != nil {
-		cb.onStateChange(cb.name, prev, state)
+	if t.onStateChange != nil {
+		t.onStateChange(t.name, prev, state)
 	}
 }
 
-// toNewGeneration resets the counts and expiry of the circuit breaker
-func (cb *CircuitBreaker) toNewGeneration(now time.Time) {
-	cb.generation++
-	cb.counts = Counts{}
+// toNewGeneration resets the counts and expiry of the tracker. Callers must hold mutex.
+func (t *Tracker) toNewGeneration(now time.Time) {
+	t.generation++
+	t.counts = Counts{}
 
 	var zero time.Time
-	switch cb.state {
+	switch t.state {
 	case StateClosed:
-		if cb.interval == 0 {
-			cb.expiry = zero
+		if t.interval == 0 {
+			t.expiry = zero
 		} else {
-			cb.expiry = now.Add(cb.interval)
+			t.expiry = now.Add(t.interval)
 		}
 	case StateOpen:
-		cb.expiry = now.Add(cb.timeout)
+		t.expiry = now.Add(t.timeout)
 	default:
-		cb.expiry = zero
+		t.expiry = zero
 	}
 }
 
-// Successes returns the number of successful requests in the current generation
-func (c Counts) Successes() uint32 {
-	return c.Requests - c.ConsecutiveFailures
+// CircuitBreaker implements the circuit breaker pattern for fault tolerance.
+// Prevents cascading failures by stopping calls to unhealthy services. Its
+// counting/state-machine logic lives in Tracker; Execute is a thin wrapper
+// that enforces the guarded-function contract on top of it.
+type CircuitBreaker = Tracker
+
+// CircuitBreakerOption configures a Tracker (and therefore a CircuitBreaker).
+type CircuitBreakerOption func(*Tracker)
+
+// WithMaxRequests sets the maximum number of requests allowed when the circuit breaker is half-open
+func WithMaxRequests(maxRequests uint32) CircuitBreakerOption {
+	return func(t *Tracker) {
+		t.maxRequests = maxRequests
+	}
 }
 
-// Failures returns the number of failed requests in the current generation
-func (c Counts) Failures() uint32 {
-	return c.ConsecutiveFailures
+// WithInterval sets the cyclic period of the closed state for the circuit breaker to clear statistics
+func WithInterval(interval time.Duration) CircuitBreakerOption {
+	return func(t *Tracker) {
+		t.interval = interval
+	}
 }
 
-// State returns the current state of the circuit breaker
-func (cb *CircuitBreaker) State() CircuitBreakerState {
-	cb.mutex.RLock()
-	defer cb.mutex.RUnlock()
+// WithTimeout sets the timeout of the open state for the circuit breaker
+func WithTimeout(timeout time.Duration) CircuitBreakerOption {
+	return func(t *Tracker) {
+		t.timeout = timeout
+	}
+}
 
-	state, _ := cb.currentState(time.Now())
-	return state
+// WithReadyToTrip sets the criteria for tripping the circuit breaker
+func WithReadyToTrip(readyToTrip func(counts Counts) bool) CircuitBreakerOption {
+	return func(t *Tracker) {
+		t.readyToTrip = readyToTrip
+	}
+}
+
+// WithOnStateChange sets the callback function to be called when the circuit breaker state changes
+func WithOnStateChange(onStateChange func(name string, from CircuitBreakerState, to CircuitBreakerState)) CircuitBreakerOption {
+	return func(t *Tracker) {
+		t.onStateChange = onStateChange
+	}
+}
+
+// WithLogger attaches a structured Logger that records every state
+// transition with {circuit_state} fields, independent of (and in addition
+// to) WithOnStateChange's callback.
+func WithLogger(logger Logger) CircuitBreakerOption {
+	return func(t *Tracker) {
+		t.logger = logger
+	}
+}
+
+// NewCircuitBreaker creates a new CircuitBreaker with the given name and options
+func NewCircuitBreaker(name string, opts ...CircuitBreakerOption) *CircuitBreaker {
+	return NewTracker(name, opts...)
+}
+
+// Execute runs the given function if the circuit breaker is available
+// It returns an error if the circuit breaker is open or the function fails
+func (t *Tracker) Execute(req func() (any, error)) (any, error) {
+	generation, err := t.OnRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		e := recover()
+		if e != nil {
+			t.OnFailure(generation)
+			panic(e)
+		}
+	}()
+
+	result, err := req()
+	if err == nil {
+		t.OnSuccess(generation)
+	} else {
+		t.OnFailure(generation)
+	}
+	return result, err
 }
 
-// Counts returns the internal counts of the circuit breaker
-func (cb *CircuitBreaker) Counts() Counts {
-	cb.mutex.RLock()
-	defer cb.mutex.RUnlock()
+// State returns the current state of the circuit breaker
+func (t *Tracker) State() CircuitBreakerState {
+	return t.CurrentState(time.Now())
+}
 
-	return cb.counts
+// ForceState overrides the tracker's state, e.g. for an operator-triggered
+// SetBackendState call. It bypasses readyToTrip and resets counts/expiry as
+// if the state had been reached normally.
+func (t *Tracker) ForceState(state CircuitBreakerState) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.setState(state, time.Now())
 }
 
 // Errors returned by the circuit breaker
 var (
-	ErrTooManyRequests = errors.New("circuit breaker is open")
+	ErrTooManyRequests    = errors.New("circuit breaker is open")
 	ErrServiceUnavailable = errors.New("service is unavailable")
 )
@@ -0,0 +1,126 @@
+package loadbalancer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// ClusterBus fans control-plane events out to every load-balancer replica
+// over RedisClient's Publish/Subscribe, namespacing every topic under
+// keyPrefix so multiple deployments can share a Redis instance. It's used to
+// invalidate local session cache entries when a backend drains, mirror
+// circuit breaker trips across replicas, and push backend add/remove/weight
+// changes without a restart.
+type ClusterBus struct {
+	redisClient RedisClient
+	keyPrefix   string
+}
+
+// NewClusterBus creates a ClusterBus publishing and subscribing under topics
+// scoped to keyPrefix.
+func NewClusterBus(redisClient RedisClient, keyPrefix string) *ClusterBus {
+	return &ClusterBus{redisClient: redisClient, keyPrefix: keyPrefix}
+}
+
+const (
+	topicSessionInvalidate = "session-invalidate"
+	topicBreakerState      = "breaker-state"
+	topicConfigUpdate      = "config-update"
+)
+
+// SessionInvalidateEvent is broadcast when a backend drains, so peers evict
+// any locally cached sticky-session entries pointing at it.
+type SessionInvalidateEvent struct {
+	BackendURL string `json:"backend_url"`
+}
+
+// BreakerStateEvent is broadcast whenever a circuit breaker changes state, so
+// peers open/close the same backend's breaker without waiting to observe the
+// failures themselves.
+type BreakerStateEvent struct {
+	BackendURL string              `json:"backend_url"`
+	State      CircuitBreakerState `json:"state"`
+}
+
+// ConfigUpdateEvent is broadcast when a backend is added, removed, or has its
+// weight changed, so replicas apply the same change without a restart.
+// Action is one of "add", "remove", or "weight".
+type ConfigUpdateEvent struct {
+	Action     string `json:"action"`
+	BackendURL string `json:"backend_url"`
+	Weight     int    `json:"weight,omitempty"`
+	Region     string `json:"region,omitempty"`
+}
+
+func (b *ClusterBus) topic(name string) string {
+	return fmt.Sprintf("%s:cluster:%s", b.keyPrefix, name)
+}
+
+func (b *ClusterBus) publish(ctx context.Context, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("clusterbus: failed to marshal payload for %s: %w", topic, err)
+	}
+	return b.redisClient.Publish(ctx, b.topic(topic), string(data))
+}
+
+// PublishSessionInvalidate broadcasts that backendURL has drained.
+func (b *ClusterBus) PublishSessionInvalidate(ctx context.Context, backendURL string) error {
+	return b.publish(ctx, topicSessionInvalidate, SessionInvalidateEvent{BackendURL: backendURL})
+}
+
+// SubscribeSessionInvalidate registers handler for session invalidation
+// events. It blocks until ctx is canceled, so callers run it in its own
+// goroutine.
+func (b *ClusterBus) SubscribeSessionInvalidate(ctx context.Context, handler func(SessionInvalidateEvent)) error {
+	return b.redisClient.Subscribe(ctx, b.topic(topicSessionInvalidate), func(payload string) {
+		var evt SessionInvalidateEvent
+		if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+			log.Printf("[WARN] ClusterBus: failed to unmarshal session-invalidate message: %v", err)
+			return
+		}
+		handler(evt)
+	})
+}
+
+// PublishBreakerState broadcasts evt to every replica watching this backend's
+// circuit breaker.
+func (b *ClusterBus) PublishBreakerState(ctx context.Context, evt BreakerStateEvent) error {
+	return b.publish(ctx, topicBreakerState, evt)
+}
+
+// SubscribeBreakerState registers handler for circuit breaker state
+// transitions observed by peers. It blocks until ctx is canceled, so callers
+// run it in its own goroutine.
+func (b *ClusterBus) SubscribeBreakerState(ctx context.Context, handler func(BreakerStateEvent)) error {
+	return b.redisClient.Subscribe(ctx, b.topic(topicBreakerState), func(payload string) {
+		var evt BreakerStateEvent
+		if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+			log.Printf("[WARN] ClusterBus: failed to unmarshal breaker-state message: %v", err)
+			return
+		}
+		handler(evt)
+	})
+}
+
+// PublishConfigUpdate broadcasts a backend add/remove/weight change to every
+// replica.
+func (b *ClusterBus) PublishConfigUpdate(ctx context.Context, evt ConfigUpdateEvent) error {
+	return b.publish(ctx, topicConfigUpdate, evt)
+}
+
+// SubscribeConfigUpdate registers handler for backend add/remove/weight
+// changes made by peers. It blocks until ctx is canceled, so callers run it
+// in its own goroutine.
+func (b *ClusterBus) SubscribeConfigUpdate(ctx context.Context, handler func(ConfigUpdateEvent)) error {
+	return b.redisClient.Subscribe(ctx, b.topic(topicConfigUpdate), func(payload string) {
+		var evt ConfigUpdateEvent
+		if err := json.Unmarshal([]byte(payload), &evt); err != nil {
+			log.Printf("[WARN] ClusterBus: failed to unmarshal config-update message: %v", err)
+			return
+		}
+		handler(evt)
+	})
+}
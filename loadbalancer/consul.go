@@ -2,23 +2,28 @@ package loadbalancer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 )
 
 // ConsulService represents a service discovered via Consul
 type ConsulService struct {
-	ID       string            `json:"ID"`
-	Name     string            `json:"Service"`
-	Address  string            `json:"Address"`
-	Port     int               `json:"Port"`
-	Weight   int               `json:"Weight"`
-	Region   string            `json:"Region"`
-	Tags     []string          `json:"Tags"`
-	Metadata map[string]string `json:"Meta"`
+	ID        string            `json:"ID"`
+	Name      string            `json:"Service"`
+	Address   string            `json:"Address"`
+	Port      int               `json:"Port"`
+	Weight    int               `json:"Weight"`
+	Region    string            `json:"Region"`
+	Partition string            `json:"Partition"` // Consul 1.11+ admin partition ("" = default)
+	Namespace string            `json:"Namespace"` // Consul Enterprise namespace ("" = default)
+	Tags      []string          `json:"Tags"`
+	Metadata  map[string]string `json:"Meta"`
 }
 
 // ConsulCatalog represents the Consul catalog API response
@@ -61,16 +66,39 @@ type ConsulServiceManager struct {
 	services    []ConsulService
 	mutex       sync.RWMutex
 	watchers    map[string]chan struct{}
+	watcherSeq  int
+
+	// pollInterval selects periodic polling instead of StartWatch's default
+	// event-driven blocking-query refresh, mirroring Traefik's
+	// consulcatalog.watch=false. Zero (the default) means watch mode.
+	pollInterval time.Duration
+}
+
+// ConsulServiceManagerOption configures optional ConsulServiceManager behavior.
+type ConsulServiceManagerOption func(*ConsulServiceManager)
+
+// WithPollInterval switches StartWatch into periodic-polling mode, re-running
+// GetServicesByService every interval instead of holding a blocking query
+// open. Use this when the ConsulClient's WatchServices isn't available or
+// when polling is preferred (Traefik's consulcatalog.watch=false equivalent).
+func WithPollInterval(interval time.Duration) ConsulServiceManagerOption {
+	return func(csm *ConsulServiceManager) {
+		csm.pollInterval = interval
+	}
 }
 
 // NewConsulServiceManager creates a new Consul service manager
-func NewConsulServiceManager(client ConsulClient, serviceName string) *ConsulServiceManager {
-	return &ConsulServiceManager{
+func NewConsulServiceManager(client ConsulClient, serviceName string, opts ...ConsulServiceManagerOption) *ConsulServiceManager {
+	csm := &ConsulServiceManager{
 		client:      client,
 		serviceName: serviceName,
 		services:    make([]ConsulService, 0),
 		watchers:    make(map[string]chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(csm)
+	}
+	return csm
 }
 
 // GetAllServices returns all discovered backends
@@ -96,10 +124,48 @@ func (csm *ConsulServiceManager) GetServicesByRegion(region string) []ConsulServ
 	return filtered
 }
 
-// StartWatch begins watching for service changes
+// GetServicesByPartition returns services filtered by Consul admin partition.
+// An empty partition is a wildcard and returns every service.
+func (csm *ConsulServiceManager) GetServicesByPartition(partition string) []ConsulService {
+	csm.mutex.RLock()
+	defer csm.mutex.RUnlock()
+
+	var filtered []ConsulService
+	for _, service := range csm.services {
+		if service.Partition == partition || partition == "" {
+			filtered = append(filtered, service)
+		}
+	}
+	return filtered
+}
+
+// GetServicesByNamespace returns services filtered by Consul Enterprise
+// namespace. An empty namespace is a wildcard and returns every service.
+func (csm *ConsulServiceManager) GetServicesByNamespace(namespace string) []ConsulService {
+	csm.mutex.RLock()
+	defer csm.mutex.RUnlock()
+
+	var filtered []ConsulService
+	for _, service := range csm.services {
+		if service.Namespace == namespace || namespace == "" {
+			filtered = append(filtered, service)
+		}
+	}
+	return filtered
+}
+
+// StartWatch begins watching for service changes. By default this holds a
+// blocking query open via the ConsulClient's WatchServices and reacts to
+// every change as it's pushed; pass WithPollInterval to NewConsulServiceManager
+// to poll on a fixed interval instead.
 func (csm *ConsulServiceManager) StartWatch(ctx context.Context) error {
+	if csm.pollInterval > 0 {
+		go csm.pollLoop(ctx)
+		return nil
+	}
+
 	serviceChan, errChan := csm.client.WatchServices(ctx, csm.serviceName)
-	
+
 	go func() {
 		for {
 			select {
@@ -112,10 +178,31 @@ func (csm *ConsulServiceManager) StartWatch(ctx context.Context) error {
 			}
 		}
 	}()
-	
+
 	return nil
 }
 
+// pollLoop re-fetches services every csm.pollInterval, for the
+// WithPollInterval polling mode.
+func (csm *ConsulServiceManager) pollLoop(ctx context.Context) {
+	ticker := time.NewTicker(csm.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			services, err := csm.client.GetServicesByService(ctx, csm.serviceName)
+			if err != nil {
+				log.Printf("[ERROR] Consul poll error: %v", err)
+				continue
+			}
+			csm.updateServices(services)
+		}
+	}
+}
+
 // updateServices updates the internal service list
 func (csm *ConsulServiceManager) updateServices(services []ConsulService) {
 	csm.mutex.Lock()
@@ -166,10 +253,18 @@ func (csm *ConsulServiceManager) GetHealthyServices(ctx context.Context) ([]Cons
 	return services, nil
 }
 
-// NotifyOnChange returns a channel that's notified when services change
+// NotifyOnChange returns a channel that's notified every time the service
+// list changes. Each call registers an independent watcher (keyed by a
+// monotonic counter, not a fixed name), so multiple subscribers - e.g. the
+// ServerPool's background refresh loop and a gRPC WatchServices stream - can
+// watch concurrently without stealing each other's channel.
 func (csm *ConsulServiceManager) NotifyOnChange() <-chan struct{} {
+	csm.mutex.Lock()
+	defer csm.mutex.Unlock()
+
 	ch := make(chan struct{}, 1)
-	csm.watchers["global"] = ch
+	csm.watcherSeq++
+	csm.watchers[fmt.Sprintf("watcher-%d", csm.watcherSeq)] = ch
 	return ch
 }
 
@@ -288,39 +383,323 @@ func (mcc *MockConsulClient) WatchServices(ctx context.Context, serviceName stri
 	return mcc.watchChan, mcc.errChan
 }
 
-// RealConsulClient implements actual Consul API communication
+// Blocking-query tuning for RealConsulClient.WatchServices: wait is the
+// Consul-side long-poll duration requested on every call (Consul caps this
+// at 10m regardless of what's asked), and the backoff bounds how long we
+// pause between retries after a failed poll before trying again.
+const (
+	consulWatchWait       = 5 * time.Minute
+	consulWatchMinBackoff = 1 * time.Second
+	consulWatchMaxBackoff = 30 * time.Second
+)
+
+// RealConsulClient implements actual Consul API communication, including
+// long-poll blocking queries (X-Consul-Index / ?index=&wait=) for
+// WatchServices. Datacenter, Partition, and Tag (all optional) are applied
+// as query-string filters to every catalog/health call made by this client.
 type RealConsulClient struct {
-	baseURL string
-	client  *http.Client
+	baseURL    string
+	client     *http.Client
+	token      string
+	datacenter string
+	partition  string // Consul 1.11+ admin partitions
+	namespace  string // Consul Enterprise namespaces
+	tag        string
+}
+
+// RealConsulClientOption configures optional RealConsulClient behavior.
+type RealConsulClientOption func(*RealConsulClient)
+
+// WithConsulToken sets the ACL token sent as X-Consul-Token on every request.
+func WithConsulToken(token string) RealConsulClientOption {
+	return func(rcc *RealConsulClient) { rcc.token = token }
+}
+
+// WithConsulDatacenter scopes every request to dc.
+func WithConsulDatacenter(dc string) RealConsulClientOption {
+	return func(rcc *RealConsulClient) { rcc.datacenter = dc }
+}
+
+// WithConsulPartition scopes every request to an admin partition (Consul
+// Enterprise 1.11+).
+func WithConsulPartition(partition string) RealConsulClientOption {
+	return func(rcc *RealConsulClient) { rcc.partition = partition }
 }
 
-// NewRealConsulClient creates a real Consul client
-func NewRealConsulClient(baseURL string) *RealConsulClient {
-	return &RealConsulClient{
+// WithConsulNamespace scopes every request to a Consul Enterprise namespace.
+func WithConsulNamespace(namespace string) RealConsulClientOption {
+	return func(rcc *RealConsulClient) { rcc.namespace = namespace }
+}
+
+// WithConsulTag filters health/catalog queries down to services carrying tag.
+func WithConsulTag(tag string) RealConsulClientOption {
+	return func(rcc *RealConsulClient) { rcc.tag = tag }
+}
+
+// NewRealConsulClient creates a real Consul client talking to the agent/server at baseURL.
+func NewRealConsulClient(baseURL string, opts ...RealConsulClientOption) *RealConsulClient {
+	rcc := &RealConsulClient{
 		baseURL: baseURL,
-		client:  &http.Client{Timeout: 10 * time.Second},
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(rcc)
 	}
+	return rcc
 }
 
+// consulFilterParams returns the dc/partition/ns/tag query params shared by
+// every catalog/health request this client makes.
+func (rcc *RealConsulClient) consulFilterParams() url.Values {
+	params := url.Values{}
+	if rcc.datacenter != "" {
+		params.Set("dc", rcc.datacenter)
+	}
+	if rcc.partition != "" {
+		params.Set("partition", rcc.partition)
+	}
+	if rcc.namespace != "" {
+		params.Set("ns", rcc.namespace)
+	}
+	if rcc.tag != "" {
+		params.Set("tag", rcc.tag)
+	}
+	return params
+}
+
+// doGet issues a GET against path with params, returning the response body,
+// the X-Consul-Index header (for blocking-query callers), and any error. A
+// non-2xx response is returned as an error including the response body.
+func (rcc *RealConsulClient) doGet(ctx context.Context, path string, params url.Values) ([]byte, string, error) {
+	reqURL := fmt.Sprintf("%s%s", rcc.baseURL, path)
+	if encoded := params.Encode(); encoded != "" {
+		reqURL = reqURL + "?" + encoded
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("consul: failed to build request for %s: %w", path, err)
+	}
+	if rcc.token != "" {
+		req.Header.Set("X-Consul-Token", rcc.token)
+	}
+
+	resp, err := rcc.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("consul: request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("consul: failed to read response from %s: %w", path, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, "", fmt.Errorf("consul: %s returned %d: %s", path, resp.StatusCode, string(body))
+	}
+
+	return body, resp.Header.Get("X-Consul-Index"), nil
+}
+
+// GetServices implements GET /v1/catalog/services, returning service name to
+// tag-set as Consul reports it directly.
 func (rcc *RealConsulClient) GetServices(ctx context.Context) (map[string][]string, error) {
-	// This would implement actual Consul API calls
-	// For now, returning empty as mock implementation
-	return map[string][]string{}, nil
+	body, _, err := rcc.doGet(ctx, "/v1/catalog/services", rcc.consulFilterParams())
+	if err != nil {
+		return nil, err
+	}
+	var services map[string][]string
+	if err := json.Unmarshal(body, &services); err != nil {
+		return nil, fmt.Errorf("consul: failed to decode catalog/services response: %w", err)
+	}
+	return services, nil
 }
 
+// consulHealthEntry mirrors one element of the /v1/health/service/{name}
+// response.
+type consulHealthEntry struct {
+	Node struct {
+		Node       string `json:"Node"`
+		Address    string `json:"Address"`
+		Datacenter string `json:"Datacenter"`
+	} `json:"Node"`
+	Service struct {
+		ID        string            `json:"ID"`
+		Service   string            `json:"Service"`
+		Tags      []string          `json:"Tags"`
+		Address   string            `json:"Address"`
+		Port      int               `json:"Port"`
+		Meta      map[string]string `json:"Meta"`
+		Namespace string            `json:"Namespace"`
+		Partition string            `json:"Partition"`
+		Weights   struct {
+			Passing int `json:"Passing"`
+		} `json:"Weights"`
+	} `json:"Service"`
+	Checks []HealthCheck `json:"Checks"`
+}
+
+// allChecksPassing reports whether every health check on entry is passing.
+func (e consulHealthEntry) allChecksPassing() bool {
+	for _, check := range e.Checks {
+		if check.Status != "passing" {
+			return false
+		}
+	}
+	return true
+}
+
+// toConsulService converts a health API entry into the ConsulService shape
+// used throughout the package, falling back to the node's address when the
+// service registration didn't specify its own and defaulting Weight to 1
+// when Consul reports no passing weight.
+func (e consulHealthEntry) toConsulService() ConsulService {
+	address := e.Service.Address
+	if address == "" {
+		address = e.Node.Address
+	}
+	weight := e.Service.Weights.Passing
+	if weight <= 0 {
+		weight = 1
+	}
+	return ConsulService{
+		ID:        e.Service.ID,
+		Name:      e.Service.Service,
+		Address:   address,
+		Port:      e.Service.Port,
+		Weight:    weight,
+		Region:    e.Service.Meta["region"],
+		Partition: e.Service.Partition,
+		Namespace: e.Service.Namespace,
+		Tags:      e.Service.Tags,
+		Metadata:  e.Service.Meta,
+	}
+}
+
+// fetchHealthyServices issues /v1/health/service/{name}?passing=true, with
+// index/wait set for a blocking query when index is non-empty. It returns
+// the raw entries (so both GetHealthyServices and GetServicesByService can
+// reuse it), plus the X-Consul-Index to pass into the next blocking call.
+func (rcc *RealConsulClient) fetchHealthyServices(ctx context.Context, serviceName, index string, wait time.Duration) ([]consulHealthEntry, string, error) {
+	params := rcc.consulFilterParams()
+	params.Set("passing", "true")
+	if index != "" {
+		params.Set("index", index)
+		params.Set("wait", wait.String())
+	}
+
+	body, newIndex, err := rcc.doGet(ctx, fmt.Sprintf("/v1/health/service/%s", serviceName), params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var entries []consulHealthEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, "", fmt.Errorf("consul: failed to decode health/service response for %s: %w", serviceName, err)
+	}
+	return entries, newIndex, nil
+}
+
+// GetHealthyServices implements GET /v1/health/service/{name}?passing=true,
+// returning every entry in the ConsulHealthService shape the interface
+// expects.
 func (rcc *RealConsulClient) GetHealthyServices(ctx context.Context, serviceName string) ([]ConsulHealthService, error) {
-	// Implement actual Consul health API call
-	return nil, fmt.Errorf("real Consul client not implemented yet")
+	entries, _, err := rcc.fetchHealthyServices(ctx, serviceName, "", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	healthy := make([]ConsulHealthService, 0, len(entries))
+	for _, entry := range entries {
+		healthy = append(healthy, ConsulHealthService{
+			Node:    entry.Node.Node,
+			Service: entry.Service.Service,
+			Checks:  entry.Checks,
+			ID:      entry.Service.ID,
+			Name:    entry.Service.Service,
+		})
+	}
+	return healthy, nil
 }
 
+// GetServicesByService implements service discovery for serviceName via the
+// health API, so the returned services are already filtered down to those
+// passing every health check.
 func (rcc *RealConsulClient) GetServicesByService(ctx context.Context, serviceName string) ([]ConsulService, error) {
-	// Implement actual Consul service discovery
-	return nil, fmt.Errorf("real Consul client not implemented yet")
+	entries, _, err := rcc.fetchHealthyServices(ctx, serviceName, "", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]ConsulService, 0, len(entries))
+	for _, entry := range entries {
+		if entry.allChecksPassing() {
+			services = append(services, entry.toConsulService())
+		}
+	}
+	return services, nil
 }
 
+// WatchServices holds a Consul blocking query open against
+// /v1/health/service/{name}?passing=true&index=&wait=, pushing an update
+// only when the catalog index advances (i.e. membership or health actually
+// changed). Errors back off exponentially between consulWatchMinBackoff and
+// consulWatchMaxBackoff before retrying; a canceled ctx ends the watch.
 func (rcc *RealConsulClient) WatchServices(ctx context.Context, serviceName string) (<-chan []ConsulService, <-chan error) {
-	// Implement actual Consul blocking query
+	servicesChan := make(chan []ConsulService, 1)
 	errChan := make(chan error, 1)
-	errChan <- fmt.Errorf("real Consul client not implemented yet")
-	return nil, errChan
+
+	go rcc.watchLoop(ctx, serviceName, servicesChan, errChan)
+
+	return servicesChan, errChan
+}
+
+func (rcc *RealConsulClient) watchLoop(ctx context.Context, serviceName string, servicesChan chan<- []ConsulService, errChan chan<- error) {
+	index := "0"
+	backoff := consulWatchMinBackoff
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		entries, newIndex, err := rcc.fetchHealthyServices(ctx, serviceName, index, consulWatchWait)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case errChan <- fmt.Errorf("consul watch for %s: %w", serviceName, err):
+			default:
+			}
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			if backoff < consulWatchMaxBackoff {
+				backoff *= 2
+				if backoff > consulWatchMaxBackoff {
+					backoff = consulWatchMaxBackoff
+				}
+			}
+			continue
+		}
+
+		backoff = consulWatchMinBackoff
+		if newIndex != "" && newIndex != index {
+			index = newIndex
+			services := make([]ConsulService, 0, len(entries))
+			for _, entry := range entries {
+				if entry.allChecksPassing() {
+					services = append(services, entry.toConsulService())
+				}
+			}
+			select {
+			case servicesChan <- services:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
 }
@@ -0,0 +1,289 @@
+package loadbalancer
+
+import (
+	"container/list"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// GeoInfo is the result of a GeoIP lookup.
+type GeoInfo struct {
+	Country     string
+	Subdivision string
+	City        string
+	Continent   string
+	ASN         uint32
+}
+
+// GeoIPProvider resolves an IP address to geographic/network info for geo
+// traffic policies. MaxMindGeoIPProvider is the production implementation;
+// tests can supply a stub.
+type GeoIPProvider interface {
+	Lookup(ip net.IP) (GeoInfo, error)
+}
+
+// geoIPCacheEntry is an LRU entry keyed by the /24 (v4) or /48 (v6) prefix of
+// a looked-up IP, so nearby addresses in the same network share one lookup.
+type geoIPCacheEntry struct {
+	key  string
+	info GeoInfo
+}
+
+// geoWatchInterval is how often MaxMindGeoIPProvider polls its database
+// files for changes on disk.
+const geoWatchInterval = 30 * time.Second
+
+// MaxMindGeoIPProvider resolves IPs via MaxMind GeoIP2/GeoLite2 mmdb
+// databases: a City database (country/subdivision/city/continent) and an
+// optional ASN database. Lookups are cached in a bounded LRU keyed by
+// network prefix, and both database files are polled for changes so
+// operators can rotate them without restarting the process.
+type MaxMindGeoIPProvider struct {
+	cityDBPath string
+	asnDBPath  string
+
+	mu      sync.RWMutex
+	cityDB  *geoip2.Reader
+	asnDB   *geoip2.Reader
+	cityMod time.Time
+	asnMod  time.Time
+
+	cacheMu  sync.Mutex
+	cacheCap int
+	cache    map[string]*list.Element
+	cacheLRU *list.List
+
+	stopWatch chan struct{}
+}
+
+// NewMaxMindGeoIPProvider opens cityDBPath (required) and asnDBPath
+// (optional - pass "" to skip ASN lookups) and starts a background watcher
+// that reopens either file when its mtime changes.
+func NewMaxMindGeoIPProvider(cityDBPath, asnDBPath string) (*MaxMindGeoIPProvider, error) {
+	p := &MaxMindGeoIPProvider{
+		cityDBPath: cityDBPath,
+		asnDBPath:  asnDBPath,
+		cacheCap:   10000,
+		cache:      make(map[string]*list.Element),
+		cacheLRU:   list.New(),
+		stopWatch:  make(chan struct{}),
+	}
+
+	if err := p.reloadCityDB(); err != nil {
+		return nil, err
+	}
+	if asnDBPath != "" {
+		if err := p.reloadASNDB(); err != nil {
+			p.cityDB.Close()
+			return nil, err
+		}
+	}
+
+	go p.watchLoop()
+	return p, nil
+}
+
+// Lookup resolves ip, serving from the LRU prefix cache when possible.
+func (p *MaxMindGeoIPProvider) Lookup(ip net.IP) (GeoInfo, error) {
+	key := geoPrefixKey(ip)
+	if info, ok := p.cacheGet(key); ok {
+		return info, nil
+	}
+
+	p.mu.RLock()
+	cityDB, asnDB := p.cityDB, p.asnDB
+	p.mu.RUnlock()
+
+	if cityDB == nil {
+		return GeoInfo{}, fmt.Errorf("geoip: no city database loaded")
+	}
+
+	city, err := cityDB.City(ip)
+	if err != nil {
+		return GeoInfo{}, fmt.Errorf("geoip: city lookup failed: %w", err)
+	}
+
+	info := GeoInfo{
+		Country:   city.Country.IsoCode,
+		Continent: city.Continent.Code,
+		City:      city.City.Names["en"],
+	}
+	if len(city.Subdivisions) > 0 {
+		info.Subdivision = city.Subdivisions[0].IsoCode
+	}
+
+	if asnDB != nil {
+		if asn, err := asnDB.ASN(ip); err == nil {
+			info.ASN = asn.AutonomousSystemNumber
+		}
+	}
+
+	p.cachePut(key, info)
+	return info, nil
+}
+
+// geoPrefixKey masks ip down to a /24 (v4) or /48 (v6) network prefix so the
+// LRU cache bounds memory by network, not by individual address.
+func geoPrefixKey(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+func (p *MaxMindGeoIPProvider) cacheGet(key string) (GeoInfo, bool) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	el, ok := p.cache[key]
+	if !ok {
+		return GeoInfo{}, false
+	}
+	p.cacheLRU.MoveToFront(el)
+	return el.Value.(*geoIPCacheEntry).info, true
+}
+
+func (p *MaxMindGeoIPProvider) cachePut(key string, info GeoInfo) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	if el, ok := p.cache[key]; ok {
+		el.Value.(*geoIPCacheEntry).info = info
+		p.cacheLRU.MoveToFront(el)
+		return
+	}
+
+	el := p.cacheLRU.PushFront(&geoIPCacheEntry{key: key, info: info})
+	p.cache[key] = el
+	if p.cacheLRU.Len() > p.cacheCap {
+		oldest := p.cacheLRU.Back()
+		if oldest != nil {
+			p.cacheLRU.Remove(oldest)
+			delete(p.cache, oldest.Value.(*geoIPCacheEntry).key)
+		}
+	}
+}
+
+// invalidateCache drops every cached lookup, used after a hot-reload since a
+// rotated database can change results for a previously-cached prefix.
+func (p *MaxMindGeoIPProvider) invalidateCache() {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+	p.cache = make(map[string]*list.Element)
+	p.cacheLRU = list.New()
+}
+
+func (p *MaxMindGeoIPProvider) reloadCityDB() error {
+	db, err := geoip2.Open(p.cityDBPath)
+	if err != nil {
+		return fmt.Errorf("geoip: failed to open city database %s: %w", p.cityDBPath, err)
+	}
+
+	stat, _ := os.Stat(p.cityDBPath)
+
+	p.mu.Lock()
+	old := p.cityDB
+	p.cityDB = db
+	if stat != nil {
+		p.cityMod = stat.ModTime()
+	}
+	p.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+func (p *MaxMindGeoIPProvider) reloadASNDB() error {
+	db, err := geoip2.Open(p.asnDBPath)
+	if err != nil {
+		return fmt.Errorf("geoip: failed to open ASN database %s: %w", p.asnDBPath, err)
+	}
+
+	stat, _ := os.Stat(p.asnDBPath)
+
+	p.mu.Lock()
+	old := p.asnDB
+	p.asnDB = db
+	if stat != nil {
+		p.asnMod = stat.ModTime()
+	}
+	p.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// watchLoop polls both database files every geoWatchInterval and hot-reloads
+// whichever one changed on disk.
+func (p *MaxMindGeoIPProvider) watchLoop() {
+	ticker := time.NewTicker(geoWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopWatch:
+			return
+		case <-ticker.C:
+			p.reloadIfChanged(p.cityDBPath, p.cityModTime(), p.reloadCityDB)
+			if p.asnDBPath != "" {
+				p.reloadIfChanged(p.asnDBPath, p.asnModTime(), p.reloadASNDB)
+			}
+		}
+	}
+}
+
+func (p *MaxMindGeoIPProvider) cityModTime() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.cityMod
+}
+
+func (p *MaxMindGeoIPProvider) asnModTime() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.asnMod
+}
+
+func (p *MaxMindGeoIPProvider) reloadIfChanged(path string, lastMod time.Time, reload func() error) {
+	stat, err := os.Stat(path)
+	if err != nil || !stat.ModTime().After(lastMod) {
+		return
+	}
+	if err := reload(); err != nil {
+		log.Printf("[ERROR] geoip: failed to hot-reload %s: %v", path, err)
+		return
+	}
+	log.Printf("[INFO] geoip: reloaded database %s", path)
+	p.invalidateCache()
+}
+
+// Close stops the hot-reload watcher and closes the underlying mmdb readers.
+func (p *MaxMindGeoIPProvider) Close() error {
+	close(p.stopWatch)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var firstErr error
+	if p.cityDB != nil {
+		if err := p.cityDB.Close(); err != nil {
+			firstErr = err
+		}
+	}
+	if p.asnDB != nil {
+		if err := p.asnDB.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -1,6 +1,7 @@
 package loadbalancer
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"sync"
@@ -62,6 +63,27 @@ func (hc *HealthChecker) Monitor(urls []string) {
 // ServerPoolInterface defines the interface for server pool operations
 type ServerPoolInterface interface {
 	HealthCheck()
+
+	// The methods below back AutoScaler: it only ever sees backend URLs and
+	// counts, never the package main Backend type itself, so it stays usable
+	// against any pool implementation (and avoids an import cycle back into
+	// package main).
+
+	// BackendCount returns how many backends are currently in the pool.
+	BackendCount() int
+	// TryScale asks the pool's distributed auto-scaling state whether to
+	// scale up or down for requestCount against threshold (see
+	// AutoScalingStateManager.ShouldScale).
+	TryScale(ctx context.Context, requestCount, threshold int64) (scaleUp, scaleDown bool, err error)
+	// AddProvisionedBackend registers a newly provisioned backend at
+	// backendURL with the pool.
+	AddProvisionedBackend(backendURL string) error
+	// RemoveProvisionedBackend drains and removes a previously
+	// autoscaler-provisioned backend. Returns false if it wasn't found.
+	RemoveProvisionedBackend(ctx context.Context, backendURL string) bool
+	// LastProvisionedBackend returns the most recently autoscaler-provisioned
+	// backend still in the pool, for scale-down to pick a removal candidate.
+	LastProvisionedBackend() (string, bool)
 }
 
 // HealthCheckLoop runs periodic health checks on all backend servers
@@ -0,0 +1,210 @@
+package loadbalancer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// HealthCheckConfig is one named Service's active health-check settings.
+// ServerPool.RegisterService passes one of these per service name, and
+// HealthCheckManager runs it independently per (service, backend) pair - so
+// the same backend URL can belong to two services with entirely different
+// probe requirements, and a probe failure against one service never marks
+// the backend down for the other.
+type HealthCheckConfig struct {
+	// Path is requested against each backend's own scheme+host.
+	Path string
+	// Method defaults to "GET".
+	Method string
+	// Interval is how often this service's probes run. Defaults to 10s.
+	Interval time.Duration
+	// Timeout bounds each probe request. Defaults to 2s.
+	Timeout time.Duration
+	// Headers are set on every probe request.
+	Headers map[string]string
+	// ExpectedStatuses lists acceptable response codes. Defaults to
+	// []int{http.StatusOK}.
+	ExpectedStatuses []int
+	// ExpectedBodyRegex, if set, must match the response body.
+	ExpectedBodyRegex *regexp.Regexp
+	// FollowRedirects controls whether the probe request follows 3xx
+	// responses. Defaults to false (a redirect is treated as whatever
+	// status it returned, not followed).
+	FollowRedirects bool
+}
+
+// WithDefaults returns cfg with every zero-value field replaced by its
+// documented default.
+func (cfg HealthCheckConfig) WithDefaults() HealthCheckConfig {
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if len(cfg.ExpectedStatuses) == 0 {
+		cfg.ExpectedStatuses = []int{http.StatusOK}
+	}
+	return cfg
+}
+
+func (cfg HealthCheckConfig) statusAccepted(status int) bool {
+	for _, want := range cfg.ExpectedStatuses {
+		if want == status {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthCheckTarget is one backend as HealthCheckManager sees it - a
+// neutral DTO (the same reason ProbeTarget/provider.BackendSpec exist) so
+// this package doesn't need ServerPool's Backend type. Key identifies the
+// backend in HealthCheckManager.OnUpdate callbacks; callers typically use
+// the backend's URL string.
+type HealthCheckTarget struct {
+	Key string
+	URL *url.URL
+}
+
+// HealthCheckManager runs one independent probing goroutine per (service,
+// backend) pair registered via RegisterService, rather than a single global
+// loop over every backend URL. Each pair's goroutine ticks at its service's
+// own HealthCheckConfig.Interval and reports results through OnUpdate,
+// keyed by service name and HealthCheckTarget.Key - so two services sharing
+// a backend compute health independently and a failure on one never evicts
+// the backend from the other.
+type HealthCheckManager struct {
+	// OnUpdate is called from a probing goroutine every time a target is
+	// probed. It must not block for long; ServerPool's registration wires
+	// it to update Backend.SetAliveForService.
+	OnUpdate func(service, targetKey string, alive bool)
+
+	client *http.Client
+
+	mu       sync.Mutex
+	services map[string]*registeredService
+}
+
+type registeredService struct {
+	cancel context.CancelFunc
+}
+
+// NewHealthCheckManager returns an empty HealthCheckManager. Attach OnUpdate
+// before the first RegisterService call.
+func NewHealthCheckManager() *HealthCheckManager {
+	return &HealthCheckManager{
+		client:   &http.Client{},
+		services: make(map[string]*registeredService),
+	}
+}
+
+// RegisterService starts one goroutine per target, each probing
+// independently at cfg.Interval. Calling RegisterService again with the
+// same name stops the previous registration's goroutines first, so a
+// service's backend list or config can be changed by re-registering it.
+func (m *HealthCheckManager) RegisterService(name string, cfg HealthCheckConfig, targets []HealthCheckTarget) {
+	cfg = cfg.WithDefaults()
+
+	m.mu.Lock()
+	if existing, ok := m.services[name]; ok {
+		existing.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.services[name] = &registeredService{cancel: cancel}
+	m.mu.Unlock()
+
+	for _, target := range targets {
+		go m.run(ctx, name, cfg, target)
+	}
+}
+
+// Stop cancels every goroutine RegisterService started for name.
+func (m *HealthCheckManager) Stop(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.services[name]; ok {
+		existing.cancel()
+		delete(m.services, name)
+	}
+}
+
+func (m *HealthCheckManager) run(ctx context.Context, service string, cfg HealthCheckConfig, target HealthCheckTarget) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	m.probeOnce(ctx, service, cfg, target)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.probeOnce(ctx, service, cfg, target)
+		}
+	}
+}
+
+func (m *HealthCheckManager) probeOnce(ctx context.Context, service string, cfg HealthCheckConfig, target HealthCheckTarget) {
+	alive := m.probe(ctx, cfg, target)
+	if m.OnUpdate != nil {
+		m.OnUpdate(service, target.Key, alive)
+	}
+}
+
+func (m *HealthCheckManager) probe(ctx context.Context, cfg HealthCheckConfig, target HealthCheckTarget) bool {
+	probeURL := *target.URL
+	probeURL.Path = cfg.Path
+	probeURL.RawQuery = ""
+
+	reqCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, cfg.Method, probeURL.String(), nil)
+	if err != nil {
+		return false
+	}
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := m.client
+	if !cfg.FollowRedirects {
+		client = &http.Client{
+			Transport: m.client.Transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	if !cfg.statusAccepted(resp.StatusCode) {
+		return false
+	}
+	if cfg.ExpectedBodyRegex != nil {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return false
+		}
+		if !cfg.ExpectedBodyRegex.Match(body) {
+			return false
+		}
+	}
+	return true
+}
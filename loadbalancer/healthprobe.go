@@ -0,0 +1,100 @@
+package loadbalancer
+
+import (
+	"regexp"
+	"time"
+)
+
+// HealthCheckOptions configures ServerPool.EnableHealthChecks. Zero-value
+// HealthCheckOptions (Path == "") leaves HealthCheck on its original
+// TCP-dial behavior; setting Path switches it to an active HTTP(S) probe
+// plus passive outlier ejection.
+type HealthCheckOptions struct {
+	// Path is the health endpoint requested against each backend's own
+	// scheme+host, e.g. "/healthz". Empty disables HTTP probing entirely -
+	// HealthCheck falls back to its original net.DialTimeout check.
+	Path string
+	// ExpectedStatus lists acceptable response status codes. Empty defaults
+	// to []int{http.StatusOK}.
+	ExpectedStatus []int
+	// BodyRegex, if set, must match the response body for the probe to pass.
+	BodyRegex *regexp.Regexp
+	// Interval is how often HealthCheckLoop should probe (informational -
+	// the loop's own ticker period is what actually governs cadence; this
+	// documents the value it's expected to be configured with).
+	Interval time.Duration
+	// Timeout bounds each probe request. Defaults to 2s if zero.
+	Timeout time.Duration
+
+	// OutlierWindow is the sliding window RecordMetrics samples are scored
+	// over for passive outlier ejection. Defaults to 30s if zero.
+	OutlierWindow time.Duration
+	// OutlierErrorRateThreshold ejects a backend once its error rate over
+	// OutlierWindow exceeds this fraction (e.g. 0.5 for 50%). Defaults to
+	// 0.5 if zero.
+	OutlierErrorRateThreshold float64
+	// OutlierMinSamples is the minimum number of RecordMetrics samples
+	// within OutlierWindow before error rate is judged meaningful - avoids
+	// ejecting a backend off one or two unlucky requests. Defaults to 5.
+	OutlierMinSamples int
+	// EjectionBaseBackoff is how long a first ejection lasts before the
+	// backend is probed again. Defaults to 10s if zero.
+	EjectionBaseBackoff time.Duration
+	// EjectionMaxBackoff caps the doubling applied on repeated ejections.
+	// Defaults to 5m if zero.
+	EjectionMaxBackoff time.Duration
+}
+
+// WithDefaults returns opts with every zero-value field replaced by its
+// documented default.
+func (opts HealthCheckOptions) WithDefaults() HealthCheckOptions {
+	if len(opts.ExpectedStatus) == 0 {
+		opts.ExpectedStatus = []int{200}
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 2 * time.Second
+	}
+	if opts.OutlierWindow == 0 {
+		opts.OutlierWindow = 30 * time.Second
+	}
+	if opts.OutlierErrorRateThreshold == 0 {
+		opts.OutlierErrorRateThreshold = 0.5
+	}
+	if opts.OutlierMinSamples == 0 {
+		opts.OutlierMinSamples = 5
+	}
+	if opts.EjectionBaseBackoff == 0 {
+		opts.EjectionBaseBackoff = 10 * time.Second
+	}
+	if opts.EjectionMaxBackoff == 0 {
+		opts.EjectionMaxBackoff = 5 * time.Minute
+	}
+	return opts
+}
+
+// EjectionBackoff returns how long the count-th ejection (1-indexed) should
+// last: EjectionBaseBackoff doubled once per prior ejection, capped at
+// EjectionMaxBackoff.
+func (opts HealthCheckOptions) EjectionBackoff(count int) time.Duration {
+	if count < 1 {
+		count = 1
+	}
+	backoff := opts.EjectionBaseBackoff
+	for i := 1; i < count; i++ {
+		backoff *= 2
+		if backoff >= opts.EjectionMaxBackoff {
+			return opts.EjectionMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// StatusAccepted reports whether status is one of ExpectedStatus.
+func (opts HealthCheckOptions) StatusAccepted(status int) bool {
+	for _, want := range opts.ExpectedStatus {
+		if want == status {
+			return true
+		}
+	}
+	return false
+}
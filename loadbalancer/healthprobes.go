@@ -0,0 +1,221 @@
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ProbeTarget is everything a HealthProbe needs to check one backend. It's a
+// neutral DTO independent of ServerPool's Backend type (package main), the
+// same reason loadbalancer.ConsulService/provider.BackendSpec exist -
+// serverpool.go's probeBackend builds one per probe call from the Backend
+// and its HealthCheckOptions/ProbeConfig.
+type ProbeTarget struct {
+	// Addr is host:port, used by TCPProbe and GRPCProbe.
+	Addr string
+	// URL is the full request URL (scheme+host+path, query stripped), used
+	// by HTTPProbe only.
+	URL string
+	// ExpectedStatus and BodyRegex are HTTPProbe's pass criteria.
+	ExpectedStatus []int
+	BodyRegex      *regexp.Regexp
+	// GRPCService is the optional grpc_health_v1.HealthCheckRequest.Service
+	// field GRPCProbe checks; "" checks the server's overall status.
+	GRPCService string
+	// Timeout bounds the probe request/RPC.
+	Timeout time.Duration
+}
+
+// statusAccepted reports whether status is one of target.ExpectedStatus,
+// defaulting to 200 if ExpectedStatus is empty.
+func (target ProbeTarget) statusAccepted(status int) bool {
+	if len(target.ExpectedStatus) == 0 {
+		return status == http.StatusOK
+	}
+	for _, want := range target.ExpectedStatus {
+		if want == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ProbeConfig carries per-backend overrides for whichever HealthProbe
+// Backend.ProbeType selects - an HTTP probe's own Path/ExpectedStatus/
+// BodyRegex, or a gRPC probe's Service name. Fields a backend's ProbeType
+// doesn't use are simply ignored; the zero value means "use the pool's
+// HealthCheckOptions defaults".
+type ProbeConfig struct {
+	// Path overrides HealthCheckOptions.Path for this backend's HTTPProbe.
+	Path           string
+	ExpectedStatus []int
+	BodyRegex      *regexp.Regexp
+	// GRPCService is the grpc_health_v1 service name this backend's
+	// GRPCProbe checks; "" checks the server's overall status.
+	GRPCService string
+}
+
+// ProbeResult is one HealthProbe.Probe outcome. Latency is recorded
+// regardless of Healthy so probeLatencyEWMA reflects failed probes too, the
+// same way it always has for HTTPProbe.
+type ProbeResult struct {
+	Healthy bool
+	Latency time.Duration
+	// Err explains why Healthy is false; nil when Healthy is true.
+	Err error
+}
+
+// HealthProbe actively checks one backend's health. ServerPool.probeBackend
+// picks an implementation per backend via Backend.ProbeType, so different
+// backends in the same pool can be probed differently (e.g. a gRPC backend
+// alongside HTTP ones).
+type HealthProbe interface {
+	Probe(ctx context.Context, target ProbeTarget) ProbeResult
+}
+
+// HTTPProbe requests target.URL and checks the response against
+// target.ExpectedStatus/BodyRegex - the probe ServerPool has always run,
+// extracted here so it implements HealthProbe like TCPProbe and GRPCProbe.
+type HTTPProbe struct {
+	Client *http.Client
+}
+
+func (p *HTTPProbe) Probe(ctx context.Context, target ProbeTarget) ProbeResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		return ProbeResult{Err: fmt.Errorf("building probe request: %w", err)}
+	}
+
+	start := time.Now()
+	resp, err := p.Client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return ProbeResult{Latency: latency, Err: fmt.Errorf("probe request failed: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if !target.statusAccepted(resp.StatusCode) {
+		return ProbeResult{Latency: latency, Err: fmt.Errorf("unexpected status %d", resp.StatusCode)}
+	}
+
+	if target.BodyRegex != nil {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return ProbeResult{Latency: latency, Err: fmt.Errorf("reading probe body: %w", err)}
+		}
+		if !target.BodyRegex.Match(body) {
+			return ProbeResult{Latency: latency, Err: fmt.Errorf("probe body did not match expected pattern")}
+		}
+	}
+
+	return ProbeResult{Healthy: true, Latency: latency}
+}
+
+// TCPProbe dials target.Addr and considers the backend healthy as soon as
+// the connection succeeds - HealthCheck's original pre-EnableHealthChecks
+// behavior, reusable per backend via Backend.ProbeType="tcp".
+type TCPProbe struct {
+	Dialer *net.Dialer
+}
+
+func (p *TCPProbe) Probe(ctx context.Context, target ProbeTarget) ProbeResult {
+	dialer := p.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", target.Addr)
+	latency := time.Since(start)
+	if err != nil {
+		return ProbeResult{Latency: latency, Err: fmt.Errorf("tcp dial failed: %w", err)}
+	}
+	conn.Close()
+	return ProbeResult{Healthy: true, Latency: latency}
+}
+
+// GRPCProbe checks a backend via grpc_health_v1.Health/Check, caching one
+// ClientConn per backend address so repeated probes (HealthCheck runs on
+// every tick) don't redial each time.
+type GRPCProbe struct {
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// NewGRPCProbe returns a GRPCProbe with an empty connection cache.
+func NewGRPCProbe() *GRPCProbe {
+	return &GRPCProbe{conns: make(map[string]*grpc.ClientConn)}
+}
+
+func (p *GRPCProbe) Probe(ctx context.Context, target ProbeTarget) ProbeResult {
+	start := time.Now()
+	conn, err := p.connFor(target.Addr)
+	if err != nil {
+		return ProbeResult{Latency: time.Since(start), Err: fmt.Errorf("grpc dial failed: %w", err)}
+	}
+
+	probeCtx := ctx
+	if target.Timeout > 0 {
+		var cancel context.CancelFunc
+		probeCtx, cancel = context.WithTimeout(ctx, target.Timeout)
+		defer cancel()
+	}
+
+	resp, err := healthpb.NewHealthClient(conn).Check(probeCtx, &healthpb.HealthCheckRequest{Service: target.GRPCService})
+	latency := time.Since(start)
+	if err != nil {
+		return ProbeResult{Latency: latency, Err: fmt.Errorf("grpc health check rpc failed: %w", err)}
+	}
+
+	switch resp.Status {
+	case healthpb.HealthCheckResponse_SERVING:
+		return ProbeResult{Healthy: true, Latency: latency}
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		return ProbeResult{Latency: latency, Err: fmt.Errorf("grpc health check reported NOT_SERVING")}
+	default:
+		return ProbeResult{Latency: latency, Err: fmt.Errorf("grpc health check reported %s", resp.Status)}
+	}
+}
+
+// connFor returns the cached ClientConn for addr, dialing (and caching) one
+// if this is the first probe against it.
+func (p *GRPCProbe) connFor(addr string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, ok := p.conns[addr]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
+
+// Close tears down every cached ClientConn. Call it when the pool holding
+// this GRPCProbe shuts down.
+func (p *GRPCProbe) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var firstErr error
+	for addr, conn := range p.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(p.conns, addr)
+	}
+	return firstErr
+}
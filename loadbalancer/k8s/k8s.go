@@ -0,0 +1,236 @@
+// Package k8s discovers backends from a Kubernetes Service's EndpointSlices,
+// mirroring loadbalancer.ConsulServiceManager's role for Consul: it only
+// tracks the discovered service set, leaving ServerPool
+// (serverpool.go's EnableKubernetesSupport/UpdateBackendsFromKubernetes) to
+// convert that into real Backends.
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// regionLabel is the well-known topology label EndpointSlices (or the nodes
+// backing them) carry their region under.
+const regionLabel = "topology.kubernetes.io/region"
+
+// serviceNameLabel is the well-known label every EndpointSlice carries the
+// owning Service's name under.
+const serviceNameLabel = "kubernetes.io/service-name"
+
+// Service represents one ready backend address discovered from an
+// EndpointSlice, analogous to loadbalancer.ConsulService.
+type Service struct {
+	Address string
+	Port    int32
+	Weight  int
+	Region  string
+}
+
+// NewClientset builds a kubernetes.Interface the same way kubectl/most
+// controllers do: in-cluster config if running inside a pod (a
+// ServiceAccount token mounted at the default path), falling back to
+// kubeconfigPath (pass "" to use the default $KUBECONFIG/~/.kube/config
+// resolution) for out-of-cluster use.
+func NewClientset(kubeconfigPath string) (kubernetes.Interface, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		cfg, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("k8s: failed to build client config (in-cluster and kubeconfig both failed): %w", err)
+		}
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("k8s: failed to build clientset: %w", err)
+	}
+	return clientset, nil
+}
+
+// ServiceManager watches one Service's EndpointSlices via a shared informer
+// and keeps an up-to-date Service list, notifying NotifyOnChange
+// subscribers on every reconcile - the same role ConsulServiceManager plays
+// for Consul.
+type ServiceManager struct {
+	client      kubernetes.Interface
+	namespace   string
+	serviceName string
+	// portName selects which of an EndpointSlice's named ports each
+	// Service's Port comes from; "" matches the slice's only port (or its
+	// first, if the Service exposes several unnamed ports).
+	portName string
+
+	mutex      sync.RWMutex
+	services   []Service
+	watchers   map[string]chan struct{}
+	watcherSeq int
+}
+
+// NewServiceManager returns a ServiceManager discovering serviceName's
+// EndpointSlices in namespace via client, resolving each backend's port
+// from the EndpointSlice port named portName ("" for the Service's sole
+// port).
+func NewServiceManager(client kubernetes.Interface, namespace, serviceName, portName string) *ServiceManager {
+	return &ServiceManager{
+		client:      client,
+		namespace:   namespace,
+		serviceName: serviceName,
+		portName:    portName,
+		watchers:    make(map[string]chan struct{}),
+	}
+}
+
+// GetAllServices returns every currently discovered, ready backend.
+func (sm *ServiceManager) GetAllServices() []Service {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	services := make([]Service, len(sm.services))
+	copy(services, sm.services)
+	return services
+}
+
+// NotifyOnChange returns a channel notified every time the discovered
+// service set changes. Each call registers an independent watcher, so
+// multiple subscribers can watch concurrently without stealing each
+// other's notifications - the same shape as
+// ConsulServiceManager.NotifyOnChange.
+func (sm *ServiceManager) NotifyOnChange() <-chan struct{} {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	ch := make(chan struct{}, 1)
+	sm.watcherSeq++
+	sm.watchers[fmt.Sprintf("watcher-%d", sm.watcherSeq)] = ch
+	return ch
+}
+
+// StartWatch starts a SharedInformerFactory scoped to namespace, reconciling
+// the Service list on every EndpointSlice add/update/delete event matching
+// serviceName. It returns once the informer's cache has synced; the
+// informer itself keeps running until ctx is canceled.
+func (sm *ServiceManager) StartWatch(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		sm.client,
+		10*time.Minute,
+		informers.WithNamespace(sm.namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = fmt.Sprintf("%s=%s", serviceNameLabel, sm.serviceName)
+		}),
+	)
+	informer := factory.Discovery().V1().EndpointSlices().Informer()
+
+	reconcile := func() { sm.reconcile(informer) }
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { reconcile() },
+		UpdateFunc: func(old, new interface{}) { reconcile() },
+		DeleteFunc: func(obj interface{}) { reconcile() },
+	})
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("k8s: cache sync failed or canceled for service %s/%s", sm.namespace, sm.serviceName)
+	}
+
+	reconcile()
+	return nil
+}
+
+// reconcile lists every EndpointSlice currently in the informer's store,
+// extracts every ready endpoint's address/port/region, and updates the
+// Service list, notifying watchers.
+func (sm *ServiceManager) reconcile(informer cache.SharedIndexInformer) {
+	var services []Service
+	for _, obj := range informer.GetStore().List() {
+		slice, ok := obj.(*discoveryv1.EndpointSlice)
+		if !ok {
+			continue
+		}
+		services = append(services, sm.servicesFromSlice(slice)...)
+	}
+
+	sm.mutex.Lock()
+	sm.services = services
+	sm.mutex.Unlock()
+
+	log.Printf("[INFO] k8s: reconciled %d backend(s) for service %s/%s", len(services), sm.namespace, sm.serviceName)
+
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	for _, ch := range sm.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// servicesFromSlice extracts one Service per ready endpoint address in
+// slice, skipping any endpoint whose Ready condition is explicitly false -
+// the kubelet's own readiness probe is the source of truth here, with our
+// circuit breaker acting as a second, independent layer on top rather than
+// a replacement for it.
+func (sm *ServiceManager) servicesFromSlice(slice *discoveryv1.EndpointSlice) []Service {
+	port := sm.resolvePort(slice)
+	if port == 0 {
+		return nil
+	}
+
+	var services []Service
+	for _, endpoint := range slice.Endpoints {
+		if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+			continue
+		}
+		region := sm.regionForEndpoint(slice, endpoint)
+		for _, addr := range endpoint.Addresses {
+			services = append(services, Service{
+				Address: addr,
+				Port:    port,
+				Weight:  1,
+				Region:  region,
+			})
+		}
+	}
+	return services
+}
+
+// resolvePort finds slice's port named sm.portName, or, if sm.portName is
+// "", the slice's first port (its only one, in the usual single-port case).
+func (sm *ServiceManager) resolvePort(slice *discoveryv1.EndpointSlice) int32 {
+	if sm.portName == "" {
+		if len(slice.Ports) == 1 && slice.Ports[0].Port != nil {
+			return *slice.Ports[0].Port
+		}
+		if len(slice.Ports) > 0 && slice.Ports[0].Port != nil {
+			return *slice.Ports[0].Port
+		}
+		return 0
+	}
+	for _, p := range slice.Ports {
+		if p.Name != nil && *p.Name == sm.portName && p.Port != nil {
+			return *p.Port
+		}
+	}
+	return 0
+}
+
+// regionForEndpoint propagates topology.kubernetes.io/region into
+// Backend.Region for geo routing: it's checked first on the endpoint's own
+// deprecated topology map (still populated by most kubelets), then on the
+// EndpointSlice's own labels for clusters that apply it there instead.
+func (sm *ServiceManager) regionForEndpoint(slice *discoveryv1.EndpointSlice, endpoint discoveryv1.Endpoint) string {
+	if region := endpoint.DeprecatedTopology[regionLabel]; region != "" {
+		return region
+	}
+	return slice.Labels[regionLabel]
+}
@@ -0,0 +1,169 @@
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Leader campaigns for a Redis-backed lease that elects a single replica to
+// drive auto-scaling decisions. Only the current leader should call
+// AutoScalingStateManager.ShouldScale; every replica (leader or not) can
+// still read AutoScalingState for local decisions like admission control.
+type Leader struct {
+	redisClient RedisClient
+	key         string
+	leaseTTL    time.Duration
+	renewEvery  time.Duration
+	id          string
+
+	mu       sync.Mutex
+	leading  bool
+	token    int64
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// leaderTokens is a process-wide counter used to mint fencing tokens each
+// time a Leader acquires the lease, so a token is always strictly greater
+// than whatever the same replica held on its last acquisition.
+var leaderTokens int64
+
+// NewLeader creates a Leader that campaigns for the lease
+// "{keyPrefix}:autoscaler:leader", identifying itself as id (e.g. a hostname
+// or pod name) in the lease value. leaseTTL is how long the lease is valid
+// without renewal; renewEvery should be comfortably shorter than leaseTTL so
+// a brief Redis hiccup doesn't cost the lease.
+func NewLeader(redisClient RedisClient, keyPrefix, id string, leaseTTL, renewEvery time.Duration) *Leader {
+	return &Leader{
+		redisClient: redisClient,
+		key:         fmt.Sprintf("%s:autoscaler:leader", keyPrefix),
+		leaseTTL:    leaseTTL,
+		renewEvery:  renewEvery,
+		id:          id,
+	}
+}
+
+// Campaign starts trying to acquire and then hold the leader lease in the
+// background, until ctx is canceled or Resign is called. It returns
+// immediately; use IsLeader to check the outcome.
+func (l *Leader) Campaign(ctx context.Context) {
+	l.mu.Lock()
+	if l.stopCh != nil {
+		l.mu.Unlock()
+		return // already campaigning
+	}
+	l.stopCh = make(chan struct{})
+	l.mu.Unlock()
+
+	go l.run(ctx)
+}
+
+func (l *Leader) run(ctx context.Context) {
+	ticker := time.NewTicker(l.renewEvery)
+	defer ticker.Stop()
+
+	l.tryAcquireOrRenew(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.release(context.Background())
+			return
+		case <-l.stopCh:
+			l.release(context.Background())
+			return
+		case <-ticker.C:
+			l.tryAcquireOrRenew(ctx)
+		}
+	}
+}
+
+// tryAcquireOrRenew attempts to either take the lease (if free) or renew it
+// (if this Leader already holds it). Any failure, including losing the
+// lease to another replica, demotes this Leader.
+func (l *Leader) tryAcquireOrRenew(ctx context.Context) {
+	if l.IsLeader() {
+		holder, err := l.redisClient.Get(ctx, l.key)
+		if err == nil && holder != l.id {
+			log.Printf("[WARN] Leader: lease %s is no longer held by %s, stepping down", l.key, l.id)
+			l.setLeading(false, 0)
+			return
+		}
+		if err := l.redisClient.Expire(ctx, l.key, l.leaseTTL); err != nil {
+			log.Printf("[WARN] Leader: failed to renew lease %s, stepping down: %v", l.key, err)
+			l.setLeading(false, 0)
+		}
+		return
+	}
+
+	acquired, err := l.redisClient.SetNX(ctx, l.key, l.id, l.leaseTTL)
+	if err != nil {
+		log.Printf("[WARN] Leader: failed to campaign for lease %s: %v", l.key, err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	token := NextFencingToken()
+	log.Printf("[INFO] Leader: %s acquired lease %s with token %d", l.id, l.key, token)
+	l.setLeading(true, token)
+}
+
+// NextFencingToken mints a fencing token from the same process-wide counter
+// Leader uses, so it's also usable by anything that makes its own fencing
+// guarantee without going through a real lease (see
+// ServerPool.EnableSoleInstanceAutoScaling).
+func NextFencingToken() int64 {
+	return atomic.AddInt64(&leaderTokens, 1)
+}
+
+// Resign releases the lease (if held) and stops campaigning.
+func (l *Leader) Resign() {
+	l.mu.Lock()
+	stopCh := l.stopCh
+	l.mu.Unlock()
+	if stopCh == nil {
+		return
+	}
+	l.stopOnce.Do(func() { close(stopCh) })
+}
+
+// release deletes the lease key if this Leader still holds it, so the next
+// campaigner doesn't have to wait out the full TTL.
+func (l *Leader) release(ctx context.Context) {
+	if !l.IsLeader() {
+		return
+	}
+	l.setLeading(false, 0)
+	if err := l.redisClient.Del(ctx, l.key); err != nil {
+		log.Printf("[WARN] Leader: failed to release lease %s: %v", l.key, err)
+	}
+}
+
+func (l *Leader) setLeading(leading bool, token int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.leading = leading
+	l.token = token
+}
+
+// IsLeader reports whether this replica currently holds the lease.
+func (l *Leader) IsLeader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.leading
+}
+
+// Token returns the fencing token from this Leader's current (or most
+// recent) lease acquisition, for passing to ShouldScale. It's only
+// meaningful while IsLeader is true.
+func (l *Leader) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.token
+}
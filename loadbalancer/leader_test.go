@@ -0,0 +1,151 @@
+package loadbalancer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMockRedisClientSetNX(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockRedisClient()
+
+	ok, err := client.SetNX(ctx, "lock", "a", time.Hour)
+	if err != nil || !ok {
+		t.Fatalf("expected first SetNX to succeed, got ok=%v err=%v", ok, err)
+	}
+
+	ok, err = client.SetNX(ctx, "lock", "b", time.Hour)
+	if err != nil || ok {
+		t.Fatalf("expected second SetNX on a held key to fail, got ok=%v err=%v", ok, err)
+	}
+
+	val, err := client.Get(ctx, "lock")
+	if err != nil || val != "a" {
+		t.Fatalf("expected value to remain %q, got %q err=%v", "a", val, err)
+	}
+}
+
+func TestMockRedisClientSetNXAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockRedisClient()
+
+	if ok, err := client.SetNX(ctx, "lock", "a", time.Millisecond); err != nil || !ok {
+		t.Fatalf("expected first SetNX to succeed, got ok=%v err=%v", ok, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	ok, err := client.SetNX(ctx, "lock", "b", time.Hour)
+	if err != nil || !ok {
+		t.Fatalf("expected SetNX to succeed once the key has expired, got ok=%v err=%v", ok, err)
+	}
+
+	val, err := client.Get(ctx, "lock")
+	if err != nil || val != "b" {
+		t.Fatalf("expected value %q after re-acquisition, got %q err=%v", "b", val, err)
+	}
+}
+
+func TestMockRedisClientExpire(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockRedisClient()
+
+	if err := client.Set(ctx, "key", "v", 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := client.Expire(ctx, "key", time.Millisecond); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	val, err := client.Get(ctx, "key")
+	if err != nil || val != "" {
+		t.Fatalf("expected key to have expired, got %q err=%v", val, err)
+	}
+}
+
+func TestLeaderCampaignAcquiresAndRenewsLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := NewMockRedisClient()
+	leader := NewLeader(client, "test", "replica-1", 50*time.Millisecond, 10*time.Millisecond)
+	leader.Campaign(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for !leader.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !leader.IsLeader() {
+		t.Fatal("expected leader to acquire the lease")
+	}
+	if leader.Token() == 0 {
+		t.Fatal("expected a non-zero fencing token once leading")
+	}
+
+	// A second Leader should not be able to acquire the held lease, even
+	// after the 50ms TTL has passed, since the first one keeps renewing it.
+	other := NewLeader(client, "test", "replica-2", 50*time.Millisecond, 10*time.Millisecond)
+	other.Campaign(ctx)
+	time.Sleep(100 * time.Millisecond)
+	if other.IsLeader() {
+		t.Fatal("expected second replica not to acquire an already-held lease")
+	}
+
+	leader.Resign()
+}
+
+func TestLeaderResignReleasesLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := NewMockRedisClient()
+	leader := NewLeader(client, "test", "replica-1", time.Second, 20*time.Millisecond)
+	leader.Campaign(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for !leader.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !leader.IsLeader() {
+		t.Fatal("expected leader to acquire the lease")
+	}
+	leader.Resign()
+
+	deadline = time.Now().Add(time.Second)
+	for leader.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if leader.IsLeader() {
+		t.Fatal("expected leader to have stepped down after Resign")
+	}
+
+	other := NewLeader(client, "test", "replica-2", time.Second, 20*time.Millisecond)
+	other.Campaign(ctx)
+	deadline = time.Now().Add(time.Second)
+	for !other.IsLeader() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !other.IsLeader() {
+		t.Fatal("expected a second replica to acquire the lease after it was released")
+	}
+	other.Resign()
+}
+
+func TestShouldScaleRejectsStaleFencingToken(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockRedisClient()
+	asm := NewAutoScalingStateManager(client, "test", time.Hour)
+
+	if _, _, err := asm.ShouldScale(ctx, 100, 20, 5); err != nil {
+		t.Fatalf("expected token 5 to be accepted, got %v", err)
+	}
+	if _, _, err := asm.ShouldScale(ctx, 100, 20, 7); err != nil {
+		t.Fatalf("expected token 7 to be accepted, got %v", err)
+	}
+	if _, _, err := asm.ShouldScale(ctx, 100, 20, 6); err == nil {
+		t.Fatal("expected a stale token to be rejected")
+	}
+}
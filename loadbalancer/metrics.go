@@ -1,37 +1,188 @@
 package loadbalancer
 
 import (
-	"log"
-	"sync"
-	"time"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-type Metrics struct {
-	mu            sync.Mutex
-	RequestCounts map[string]int
-	ResponseTimes map[string]time.Duration
+// LBMetrics is the Prometheus-backed metrics surface for request, backend,
+// rate-limit, and sticky-session observability - the lb_-prefixed
+// collectors this replaces the old unused Metrics/NewMetrics (per-server
+// request count and last response time, printed via log.Println) with.
+// Construct once with NewLBMetrics and share the result across ServerPool,
+// RateLimiter, and the backends' CircuitBreakers.
+type LBMetrics struct {
+	requestsTotal       *prometheus.CounterVec
+	requestDuration     *prometheus.HistogramVec
+	backendUp           *prometheus.GaugeVec
+	backendActiveConns  *prometheus.GaugeVec
+	backendScore        *prometheus.GaugeVec
+	circuitBreakerState *prometheus.GaugeVec
+	rateLimitedTotal    *prometheus.CounterVec
+	stickySessionHits   *prometheus.CounterVec
 }
 
-func NewMetrics() *Metrics {
-	return &Metrics{
-		RequestCounts: make(map[string]int),
-		ResponseTimes: make(map[string]time.Duration),
+// NewLBMetrics creates an LBMetrics and registers its collectors against reg.
+func NewLBMetrics(reg *prometheus.Registry) *LBMetrics {
+	m := &LBMetrics{
+		requestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "lb_requests_total",
+				Help: "Total number of requests routed to a backend",
+			},
+			[]string{"backend", "region", "status_class", "policy"},
+		),
+		requestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "lb_request_duration_seconds",
+				Help:    "Request duration in seconds, from backend selection to response completion",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			},
+			[]string{"backend"},
+		),
+		backendUp: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "lb_backend_up",
+				Help: "Whether a backend is currently considered alive (1) or down (0)",
+			},
+			[]string{"backend"},
+		),
+		backendActiveConns: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "lb_backend_active_connections",
+				Help: "Number of requests currently in flight to a backend",
+			},
+			[]string{"backend"},
+		),
+		backendScore: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "lb_backend_score",
+				Help: "Backend selection score (lower is preferred); see Backend.Score",
+			},
+			[]string{"backend"},
+		),
+		circuitBreakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "lb_circuit_breaker_state",
+				Help: "Circuit breaker state per backend (0=closed, 1=open, 2=half-open)",
+			},
+			[]string{"backend"},
+		),
+		rateLimitedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "lb_rate_limited_total",
+				Help: "Total number of requests rejected by a rate limiter",
+			},
+			[]string{"key_type"},
+		),
+		stickySessionHits: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "lb_sticky_session_hits_total",
+				Help: "Total number of requests routed via an existing sticky session assignment",
+			},
+			[]string{"source"},
+		),
 	}
+
+	if reg != nil {
+		reg.MustRegister(m.requestsTotal)
+		reg.MustRegister(m.requestDuration)
+		reg.MustRegister(m.backendUp)
+		reg.MustRegister(m.backendActiveConns)
+		reg.MustRegister(m.backendScore)
+		reg.MustRegister(m.circuitBreakerState)
+		reg.MustRegister(m.rateLimitedTotal)
+		reg.MustRegister(m.stickySessionHits)
+	}
+
+	return m
+}
+
+// RecordRequest records one completed request's outcome. statusClass is the
+// "2xx"/"4xx"/"5xx"-style bucket (see StatusClass); policy is the name of
+// the traffic policy that routed it, or "" if none applied.
+func (m *LBMetrics) RecordRequest(backend, region, statusClass, policy string) {
+	if m == nil {
+		return
+	}
+	m.requestsTotal.WithLabelValues(backend, region, statusClass, policy).Inc()
+}
+
+// ObserveDuration records one request's duration against backend's histogram.
+func (m *LBMetrics) ObserveDuration(backend string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.requestDuration.WithLabelValues(backend).Observe(seconds)
+}
+
+// SetBackendUp records whether backend is currently alive.
+func (m *LBMetrics) SetBackendUp(backend string, up bool) {
+	if m == nil {
+		return
+	}
+	value := 0.0
+	if up {
+		value = 1
+	}
+	m.backendUp.WithLabelValues(backend).Set(value)
 }
 
-func (m *Metrics) LogRequest(server string, duration time.Duration) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.RequestCounts[server]++
-	m.ResponseTimes[server] = duration
+// SetActiveConnections records backend's current in-flight request count.
+func (m *LBMetrics) SetActiveConnections(backend string, count int64) {
+	if m == nil {
+		return
+	}
+	m.backendActiveConns.WithLabelValues(backend).Set(float64(count))
+}
+
+// SetBackendScore records backend's current selection score.
+func (m *LBMetrics) SetBackendScore(backend string, score float64) {
+	if m == nil {
+		return
+	}
+	m.backendScore.WithLabelValues(backend).Set(score)
+}
+
+// SetCircuitBreakerState records backend's current circuit breaker state.
+func (m *LBMetrics) SetCircuitBreakerState(backend string, state CircuitBreakerState) {
+	if m == nil {
+		return
+	}
+	m.circuitBreakerState.WithLabelValues(backend).Set(float64(state))
+}
+
+// RecordRateLimited records one request rejected by a rate limiter, keyed by
+// the kind of key it was limited on (e.g. "ip", "session", path prefixes).
+func (m *LBMetrics) RecordRateLimited(keyType string) {
+	if m == nil {
+		return
+	}
+	m.rateLimitedTotal.WithLabelValues(keyType).Inc()
+}
+
+// RecordStickySessionHit records one request routed via an existing sticky
+// session assignment, keyed by where the assignment was found ("redis" or
+// "local").
+func (m *LBMetrics) RecordStickySessionHit(source string) {
+	if m == nil {
+		return
+	}
+	m.stickySessionHits.WithLabelValues(source).Inc()
 }
 
-func (m *Metrics) PrintMetrics() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	log.Println("=== Metrics ===")
-	for server, count := range m.RequestCounts {
-		log.Printf("%s => %d requests | Last response time: %v\n", server, count, m.ResponseTimes[server])
+// StatusClass buckets an HTTP status code into Prometheus's conventional
+// "2xx"/"3xx"/"4xx"/"5xx" label value.
+func StatusClass(statusCode int) string {
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return "2xx"
+	case statusCode >= 300 && statusCode < 400:
+		return "3xx"
+	case statusCode >= 400 && statusCode < 500:
+		return "4xx"
+	case statusCode >= 500:
+		return "5xx"
+	default:
+		return "unknown"
 	}
-	log.Println("================")
 }
@@ -0,0 +1,348 @@
+// Package compress provides response compression middleware negotiating
+// Brotli and gzip from the request's Accept-Encoding header.
+package compress
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// CompressOptions configures Compress.
+type CompressOptions struct {
+	// AllowedTypes is the Content-Type allow-list eligible for compression,
+	// matched by MIME type (parameters like "; charset=utf-8" stripped)
+	// with an optional "prefix/*" wildcard. Empty uses DefaultAllowedTypes.
+	AllowedTypes []string
+	// MinSize is the minimum response body size, in bytes, before
+	// compression is worth it. Defaults to 1024.
+	MinSize int
+	// GzipLevel is passed to gzip.NewWriterLevel; 0 uses gzip.DefaultCompression.
+	GzipLevel int
+	// BrotliLevel is passed to brotli.NewWriterLevel; 0 uses brotli.DefaultCompression.
+	BrotliLevel int
+}
+
+// DefaultAllowedTypes is the Content-Type allow-list Compress uses when
+// CompressOptions.AllowedTypes is empty.
+var DefaultAllowedTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// alreadyCompressedTypes are skipped regardless of AllowedTypes - recompressing
+// them wastes CPU for little or no size benefit.
+var alreadyCompressedTypes = map[string]bool{
+	"image/png":                true,
+	"image/jpeg":               true,
+	"image/gif":                true,
+	"image/webp":               true,
+	"video/mp4":                true,
+	"video/webm":               true,
+	"audio/mpeg":               true,
+	"application/zip":          true,
+	"application/gzip":         true,
+	"application/x-gzip":       true,
+	"application/octet-stream": true,
+}
+
+func (opts CompressOptions) withDefaults() CompressOptions {
+	if len(opts.AllowedTypes) == 0 {
+		opts.AllowedTypes = DefaultAllowedTypes
+	}
+	if opts.MinSize <= 0 {
+		opts.MinSize = 1024
+	}
+	return opts
+}
+
+// Compress wraps next with response compression: it negotiates br/gzip from
+// the request's Accept-Encoding (respecting q-values), and - if the
+// response's Content-Type lands in opts.AllowedTypes, the response isn't
+// already encoded, Cache-Control doesn't say no-transform, and the body
+// reaches opts.MinSize - compresses the body, setting Content-Encoding and
+// Vary: Accept-Encoding and dropping Content-Length (the compressed size
+// isn't known up front). It's a request-path feature, so it doesn't buffer
+// the whole response: only the first MinSize bytes are held back to make
+// that decision, then streamed straight through (compressed or not) after
+// that, and on every Flush in between.
+func Compress(next http.Handler, opts CompressOptions) http.Handler {
+	opts = opts.withDefaults()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, opts: opts, encoding: encoding}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter buffers up to opts.MinSize bytes to decide whether the
+// response qualifies for compression, then streams everything from that
+// point on straight through the chosen path (compressed or not) without
+// further buffering.
+type compressWriter struct {
+	http.ResponseWriter
+	opts     CompressOptions
+	encoding string
+
+	statusCode int
+	headerSent bool
+	buf        []byte
+	decided    bool
+	compress   bool
+	encoder    io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	// Held back until decide() knows whether Content-Encoding needs setting.
+	cw.statusCode = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.decided {
+		if cw.compress {
+			return cw.encoder.Write(p)
+		}
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) < cw.opts.MinSize {
+		return len(p), nil
+	}
+	if err := cw.decide(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush decides now (in case MinSize was never reached) and forwards to the
+// underlying http.Flusher, so streaming handlers that flush mid-response
+// still reach the client promptly.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		_ = cw.decide()
+	}
+	if cw.compress {
+		if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+			_ = f.Flush()
+		}
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack passes through to the underlying ResponseWriter for handlers that
+// need a raw connection (e.g. WebSocket upgrades), which compression
+// shouldn't otherwise interfere with.
+func (cw *compressWriter) Hijack() (net.Conn, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("compress: underlying ResponseWriter does not support Hijack")
+	}
+	return hj.Hijack()
+}
+
+// Close finalizes the response: decides (if Write never reached MinSize, so
+// a small or empty body still gets its header written) and closes the
+// compressor so it flushes its trailer.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if err := cw.decide(); err != nil {
+			return err
+		}
+	}
+	if cw.compress {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+// decide inspects the headers set so far (and, if Content-Type was never
+// set explicitly, the buffered bytes via http.DetectContentType) to pick
+// compressed or passthrough, then writes the status line and whatever's
+// buffered down the chosen path.
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	header := cw.ResponseWriter.Header()
+
+	if len(cw.buf) < cw.opts.MinSize || header.Get("Content-Encoding") != "" || containsNoTransform(header.Get("Cache-Control")) || !cw.typeAllowed(header) {
+		cw.writeHeader()
+		_, err := cw.ResponseWriter.Write(cw.buf)
+		cw.buf = nil
+		return err
+	}
+
+	header.Set("Vary", addVary(header.Get("Vary"), "Accept-Encoding"))
+	header.Set("Content-Encoding", cw.encoding)
+	header.Del("Content-Length")
+
+	switch cw.encoding {
+	case "br":
+		level := cw.opts.BrotliLevel
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+		cw.encoder = brotli.NewWriterLevel(cw.ResponseWriter, level)
+	case "gzip":
+		level := cw.opts.GzipLevel
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		gz, err := gzip.NewWriterLevel(cw.ResponseWriter, level)
+		if err != nil {
+			return err
+		}
+		cw.encoder = gz
+	}
+	cw.compress = true
+	cw.writeHeader()
+
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	_, err := cw.encoder.Write(cw.buf)
+	cw.buf = nil
+	return err
+}
+
+func (cw *compressWriter) writeHeader() {
+	if cw.headerSent {
+		return
+	}
+	cw.headerSent = true
+	status := cw.statusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+// typeAllowed reports whether the response's Content-Type (explicit, or
+// sniffed from the buffered bytes if the handler never set one) matches
+// opts.AllowedTypes and isn't a type that's already compressed.
+func (cw *compressWriter) typeAllowed(header http.Header) bool {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	}
+	mediaType = strings.ToLower(mediaType)
+
+	if alreadyCompressedTypes[mediaType] {
+		return false
+	}
+
+	for _, allowed := range cw.opts.AllowedTypes {
+		allowed = strings.ToLower(allowed)
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding parses an Accept-Encoding header (respecting q-values)
+// and returns the highest-priority encoding among "br" and "gzip" the
+// client accepts, preferring br on a tie; "" means neither is acceptable
+// (including no header at all), so the caller should serve uncompressed.
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	priority := map[string]int{"br": 2, "gzip": 1}
+	best := ""
+	bestQ := -1.0
+	bestPriority := -1
+
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name := part
+		q := 1.0
+		if semi := strings.Index(part, ";"); semi != -1 {
+			name = strings.TrimSpace(part[:semi])
+			if v, ok := strings.CutPrefix(strings.TrimSpace(part[semi+1:]), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		name = strings.ToLower(name)
+		if q <= 0 {
+			continue
+		}
+
+		candidates := []string{name}
+		if name == "*" {
+			candidates = []string{"br", "gzip"}
+		}
+		for _, candidate := range candidates {
+			p, ok := priority[candidate]
+			if !ok {
+				continue
+			}
+			if q > bestQ || (q == bestQ && p > bestPriority) {
+				best = candidate
+				bestQ = q
+				bestPriority = p
+			}
+		}
+	}
+	return best
+}
+
+// containsNoTransform reports whether cacheControl carries a no-transform
+// directive, which forbids any intermediary (including this middleware)
+// from altering the response body.
+func containsNoTransform(cacheControl string) bool {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-transform") {
+			return true
+		}
+	}
+	return false
+}
+
+// addVary appends value to an existing Vary header value, unless it's
+// already present (case-insensitively).
+func addVary(existing, value string) string {
+	if existing == "" {
+		return value
+	}
+	for _, v := range strings.Split(existing, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return existing
+		}
+	}
+	return existing + ", " + value
+}
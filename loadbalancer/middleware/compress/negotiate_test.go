@@ -0,0 +1,60 @@
+package compress
+
+import "testing"
+
+func TestNegotiateEncodingEmptyHeader(t *testing.T) {
+	if got := negotiateEncoding(""); got != "" {
+		t.Fatalf("expected no header to negotiate to no encoding, got %q", got)
+	}
+}
+
+func TestNegotiateEncodingPrefersBrOnTie(t *testing.T) {
+	if got := negotiateEncoding("gzip, br"); got != "br" {
+		t.Fatalf("expected br to be preferred over gzip at equal q, got %q", got)
+	}
+}
+
+func TestNegotiateEncodingRespectsQValues(t *testing.T) {
+	if got := negotiateEncoding("br;q=0.1, gzip;q=0.9"); got != "gzip" {
+		t.Fatalf("expected gzip to win with a higher q-value, got %q", got)
+	}
+}
+
+func TestNegotiateEncodingZeroQExcludesEncoding(t *testing.T) {
+	if got := negotiateEncoding("br;q=0, gzip"); got != "gzip" {
+		t.Fatalf("expected a q=0 encoding to be excluded, got %q", got)
+	}
+}
+
+func TestNegotiateEncodingWildcardExpandsToBrAndGzip(t *testing.T) {
+	if got := negotiateEncoding("*"); got != "br" {
+		t.Fatalf("expected \"*\" to expand and prefer br, got %q", got)
+	}
+}
+
+func TestNegotiateEncodingUnsupportedEncodingYieldsNone(t *testing.T) {
+	if got := negotiateEncoding("deflate;q=1.0"); got != "" {
+		t.Fatalf("expected an unsupported encoding to negotiate to no encoding, got %q", got)
+	}
+}
+
+func TestContainsNoTransform(t *testing.T) {
+	if !containsNoTransform("public, no-transform") {
+		t.Fatal("expected no-transform directive to be detected")
+	}
+	if containsNoTransform("public, max-age=60") {
+		t.Fatal("expected a Cache-Control without no-transform to report false")
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	if got := addVary("", "Accept-Encoding"); got != "Accept-Encoding" {
+		t.Fatalf("expected empty existing value to become just the new value, got %q", got)
+	}
+	if got := addVary("Accept-Encoding", "Accept-Encoding"); got != "Accept-Encoding" {
+		t.Fatalf("expected addVary to avoid duplicating an already-present value, got %q", got)
+	}
+	if got := addVary("Origin", "Accept-Encoding"); got != "Origin, Accept-Encoding" {
+		t.Fatalf("expected addVary to append a new value, got %q", got)
+	}
+}
@@ -56,3 +56,48 @@ func NewMTLSTransportFromEnv() (*http.Transport, error) {
 
     return &http.Transport{TLSClientConfig: tlsCfg}, nil
 }
+
+// NewMTLSServerFromEnv builds a server-side *tls.Config counterpart to
+// NewMTLSTransportFromEnv, using the same MTLS_ENABLED/MTLS_CERT_FILE/
+// MTLS_KEY_FILE/MTLS_CA_FILE env vars. MTLS_CA_FILE, if set, is trusted for
+// verifying client certificates (mutual TLS) rather than server ones, and
+// switches ClientAuth to require them. Returns nil, nil if MTLS_ENABLED
+// isn't "true".
+func NewMTLSServerFromEnv() (*tls.Config, error) {
+    if os.Getenv("MTLS_ENABLED") != "true" {
+        return nil, nil
+    }
+
+    certFile := os.Getenv("MTLS_CERT_FILE")
+    keyFile := os.Getenv("MTLS_KEY_FILE")
+    caFile := os.Getenv("MTLS_CA_FILE")
+
+    if certFile == "" || keyFile == "" {
+        return nil, fmt.Errorf("mTLS enabled but MTLS_CERT_FILE/MTLS_KEY_FILE not provided")
+    }
+
+    cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load server certificate: %w", err)
+    }
+
+    tlsCfg := &tls.Config{
+        Certificates: []tls.Certificate{cert},
+        MinVersion:   tls.VersionTLS12,
+    }
+
+    if caFile != "" {
+        caCert, err := ioutil.ReadFile(caFile)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read CA file: %w", err)
+        }
+        clientCAs := x509.NewCertPool()
+        if ok := clientCAs.AppendCertsFromPEM(caCert); !ok {
+            return nil, fmt.Errorf("failed to append CA certificate")
+        }
+        tlsCfg.ClientCAs = clientCAs
+        tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+    }
+
+    return tlsCfg, nil
+}
@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// Docker container label keys DockerProvider reads to build each backend's
+// BackendSpec. A container only becomes a backend if it carries
+// dockerLabelEnable=true.
+const (
+	dockerLabelEnable = "lb.enable"
+	dockerLabelWeight = "lb.weight"
+	dockerLabelRegion = "lb.region"
+	dockerLabelPort   = "lb.port"
+)
+
+// DockerProvider discovers backends from running containers carrying
+// lb.enable=true, republishing the full set whenever the Docker daemon
+// reports a container start/stop/die/destroy event. A container's address
+// is built from its first network's IP and the lb.port label (default
+// 80); lb.weight and lb.region map onto BackendSpec the same way they do
+// for KubernetesProvider's EndpointSlice annotations.
+type DockerProvider struct {
+	Name   string
+	Client *client.Client
+}
+
+// NewDockerProvider returns a DockerProvider using dockerClient (typically
+// client.NewClientWithOpts(client.FromEnv)) to talk to the daemon.
+func NewDockerProvider(name string, dockerClient *client.Client) *DockerProvider {
+	return &DockerProvider{Name: name, Client: dockerClient}
+}
+
+// Provide publishes the current matching containers once, then watches the
+// Docker event stream and republishes on every container
+// start/stop/die/destroy event until ctx is canceled.
+func (dp *DockerProvider) Provide(ctx context.Context, configChan chan<- Message) error {
+	if err := dp.publish(ctx, configChan); err != nil {
+		return fmt.Errorf("docker provider %s: %w", dp.Name, err)
+	}
+
+	filterArgs := filters.NewArgs(
+		filters.Arg("type", string(events.ContainerEventType)),
+		filters.Arg("event", "start"),
+		filters.Arg("event", "stop"),
+		filters.Arg("event", "die"),
+		filters.Arg("event", "destroy"),
+	)
+	eventChan, errChan := dp.Client.Events(ctx, types.EventsOptions{Filters: filterArgs})
+
+	go dp.watchLoop(ctx, eventChan, errChan, configChan)
+	return nil
+}
+
+func (dp *DockerProvider) watchLoop(ctx context.Context, eventChan <-chan events.Message, errChan <-chan error, configChan chan<- Message) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case _, ok := <-eventChan:
+			if !ok {
+				return
+			}
+			if err := dp.publish(ctx, configChan); err != nil {
+				log.Printf("[ERROR] docker provider %s: %v", dp.Name, err)
+			}
+
+		case err, ok := <-errChan:
+			if !ok {
+				return
+			}
+			log.Printf("[ERROR] docker provider %s: event stream error: %v", dp.Name, err)
+		}
+	}
+}
+
+// publish lists every running container carrying lb.enable=true and
+// republishes them as a Message.
+func (dp *DockerProvider) publish(ctx context.Context, configChan chan<- Message) error {
+	containers, err := dp.Client.ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", dockerLabelEnable+"=true")),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	specs := make([]BackendSpec, 0, len(containers))
+	for _, c := range containers {
+		spec, ok := dp.backendFromContainer(c)
+		if !ok {
+			continue
+		}
+		specs = append(specs, spec)
+	}
+
+	select {
+	case configChan <- Message{ProviderName: dp.Name, Backends: specs}:
+		log.Printf("[INFO] docker provider %s: published %d backends", dp.Name, len(specs))
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// backendFromContainer builds a BackendSpec from c's first network's IP and
+// its lb.* labels. Returns ok=false if c has no network attached yet (e.g.
+// still starting).
+func (dp *DockerProvider) backendFromContainer(c types.Container) (BackendSpec, bool) {
+	var ip string
+	for _, net := range c.NetworkSettings.Networks {
+		if net.IPAddress != "" {
+			ip = net.IPAddress
+			break
+		}
+	}
+	if ip == "" {
+		return BackendSpec{}, false
+	}
+
+	port := 80
+	if v := c.Labels[dockerLabelPort]; v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			port = parsed
+		}
+	}
+
+	weight := 1
+	if v := c.Labels[dockerLabelWeight]; v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			weight = parsed
+		}
+	}
+
+	return BackendSpec{
+		URL:    fmt.Sprintf("http://%s:%d", ip, port),
+		Weight: weight,
+		Region: c.Labels[dockerLabelRegion],
+		Labels: c.Labels,
+	}, true
+}
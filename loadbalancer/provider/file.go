@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// FileProvider watches a YAML file listing backends and republishes it as a
+// Message every time the file is written. The file's shape is:
+//
+//	backends:
+//	  - url: http://10.0.1.5:8080
+//	    weight: 3
+//	    region: us-east
+type FileProvider struct {
+	Path string
+	Name string
+}
+
+// NewFileProvider returns a FileProvider watching path, named name (used as
+// the Message's ProviderName).
+func NewFileProvider(name, path string) *FileProvider {
+	return &FileProvider{Path: path, Name: name}
+}
+
+// fileConfig is the YAML document FileProvider parses Path as.
+type fileConfig struct {
+	Backends []fileBackend `yaml:"backends"`
+}
+
+type fileBackend struct {
+	URL    string `yaml:"url"`
+	Weight int    `yaml:"weight"`
+	Region string `yaml:"region"`
+}
+
+// Provide reads Path once, publishes it, then watches Path with fsnotify and
+// republishes on every Write/Create event (a Create covers editors that
+// write a new file and rename it over the original). Provide itself only
+// blocks long enough to do the first read; the fsnotify watch runs in its
+// own goroutine until ctx is canceled.
+func (fp *FileProvider) Provide(ctx context.Context, configChan chan<- Message) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("file provider %s: failed to create watcher: %w", fp.Name, err)
+	}
+	if err := watcher.Add(fp.Path); err != nil {
+		watcher.Close()
+		return fmt.Errorf("file provider %s: failed to watch %s: %w", fp.Name, fp.Path, err)
+	}
+
+	if err := fp.publish(configChan); err != nil {
+		watcher.Close()
+		return fmt.Errorf("file provider %s: %w", fp.Name, err)
+	}
+
+	go fp.watchLoop(ctx, watcher, configChan)
+	return nil
+}
+
+func (fp *FileProvider) watchLoop(ctx context.Context, watcher *fsnotify.Watcher, configChan chan<- Message) {
+	defer watcher.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if err := fp.publish(configChan); err != nil {
+				log.Printf("[ERROR] file provider %s: %v", fp.Name, err)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[ERROR] file provider %s: watcher error: %v", fp.Name, err)
+		}
+	}
+}
+
+// publish reads and parses Path and sends it as a Message.
+func (fp *FileProvider) publish(configChan chan<- Message) error {
+	data, err := os.ReadFile(fp.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", fp.Path, err)
+	}
+
+	var cfg fileConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", fp.Path, err)
+	}
+
+	specs := make([]BackendSpec, 0, len(cfg.Backends))
+	for _, b := range cfg.Backends {
+		weight := b.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		specs = append(specs, BackendSpec{URL: b.URL, Weight: weight, Region: b.Region})
+	}
+
+	configChan <- Message{ProviderName: fp.Name, Backends: specs}
+	log.Printf("[INFO] file provider %s: published %d backends from %s", fp.Name, len(specs), fp.Path)
+	return nil
+}
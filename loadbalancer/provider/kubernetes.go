@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// KubernetesProvider discovers backends from a namespace's EndpointSlices
+// via a client-go informer, republishing the full set of ready addresses
+// every time any matching EndpointSlice is added, updated, or removed.
+// Services are selected by LabelSelector (e.g. "app=web"); Weight comes
+// from the "lb.weight" annotation on the slice (default 1) and Region from
+// "lb.region", matching the lb.weight/lb.region convention DockerProvider
+// uses for container labels.
+type KubernetesProvider struct {
+	Name          string
+	Client        kubernetes.Interface
+	Namespace     string
+	LabelSelector string
+	Port          int32
+
+	// ResyncPeriod is how often the informer re-lists as a safety net
+	// against missed watch events, mirroring client-go's own
+	// SharedInformerFactory convention. Defaults to 10 minutes.
+	ResyncPeriod time.Duration
+}
+
+// NewKubernetesProvider returns a KubernetesProvider discovering
+// EndpointSlices in namespace matching labelSelector, using port as each
+// backend's port (EndpointSlices can expose several; this provider always
+// picks the one matching Port so a multi-port Service doesn't produce
+// ambiguous backends).
+func NewKubernetesProvider(name string, client kubernetes.Interface, namespace, labelSelector string, port int32) *KubernetesProvider {
+	return &KubernetesProvider{
+		Name:          name,
+		Client:        client,
+		Namespace:     namespace,
+		LabelSelector: labelSelector,
+		Port:          port,
+		ResyncPeriod:  10 * time.Minute,
+	}
+}
+
+// Provide starts a SharedInformerFactory scoped to Namespace/LabelSelector
+// and republishes the full backend set on every EndpointSlice add/update/
+// delete. It returns once the informer's cache has synced once.
+func (kp *KubernetesProvider) Provide(ctx context.Context, configChan chan<- Message) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		kp.Client,
+		kp.ResyncPeriod,
+		informers.WithNamespace(kp.Namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = kp.LabelSelector
+		}),
+	)
+	informer := factory.Discovery().V1().EndpointSlices().Informer()
+
+	publish := func() { kp.publish(ctx, informer, configChan) }
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { publish() },
+		UpdateFunc: func(old, new interface{}) { publish() },
+		DeleteFunc: func(obj interface{}) { publish() },
+	})
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return fmt.Errorf("kubernetes provider %s: cache sync failed or canceled", kp.Name)
+	}
+
+	publish()
+	return nil
+}
+
+// publish lists every EndpointSlice currently in the informer's store and
+// republishes the ready addresses as a Message.
+func (kp *KubernetesProvider) publish(ctx context.Context, informer cache.SharedIndexInformer, configChan chan<- Message) {
+	var specs []BackendSpec
+	for _, obj := range informer.GetStore().List() {
+		slice, ok := obj.(*discoveryv1.EndpointSlice)
+		if !ok {
+			continue
+		}
+		specs = append(specs, kp.backendsFromSlice(slice)...)
+	}
+
+	select {
+	case configChan <- Message{ProviderName: kp.Name, Backends: specs}:
+		log.Printf("[INFO] kubernetes provider %s: published %d backends", kp.Name, len(specs))
+	case <-ctx.Done():
+	}
+}
+
+// backendsFromSlice extracts one BackendSpec per ready address in slice
+// exposing kp.Port.
+func (kp *KubernetesProvider) backendsFromSlice(slice *discoveryv1.EndpointSlice) []BackendSpec {
+	var targetPort int32
+	for _, p := range slice.Ports {
+		if p.Port != nil && *p.Port == kp.Port {
+			targetPort = kp.Port
+			break
+		}
+	}
+	if targetPort == 0 {
+		return nil
+	}
+
+	weight := 1
+	if v, ok := slice.Annotations["lb.weight"]; ok {
+		fmt.Sscanf(v, "%d", &weight)
+		if weight <= 0 {
+			weight = 1
+		}
+	}
+	region := slice.Annotations["lb.region"]
+
+	var specs []BackendSpec
+	for _, endpoint := range slice.Endpoints {
+		if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+			continue
+		}
+		for _, addr := range endpoint.Addresses {
+			specs = append(specs, BackendSpec{
+				URL:    fmt.Sprintf("http://%s:%d", addr, targetPort),
+				Weight: weight,
+				Region: region,
+				Labels: slice.Labels,
+			})
+		}
+	}
+	return specs
+}
@@ -0,0 +1,155 @@
+// Package provider implements Traefik-style dynamic configuration
+// providers: each one watches a single external source - a file, a
+// Kubernetes service's Endpoints/EndpointSlices, or Docker container events
+// - and publishes the backend set it currently sees. ServerPool fans every
+// provider's updates through an Aggregator and applies them with
+// AddBackend/RemoveBackend/UpdateBackend (see
+// ServerPool.EnableDynamicProviders in serverpool.go), so backend
+// membership tracks the source without a restart, and a backend untouched
+// by a given update keeps its CircuitBreaker/health/EWMA state instead of
+// being torn down and rebuilt.
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// BackendSpec describes one backend a Provider discovered. It's independent
+// of ServerPool's own Backend type, which lives in package main and can't
+// be imported here without an import cycle (main already imports
+// loadbalancer) - main.go converts a BackendSpec into a *Backend the same
+// way it already does for loadbalancer.ConsulService.
+type BackendSpec struct {
+	URL    string
+	Weight int
+	Region string
+	// Labels carries source-specific metadata a caller may want beyond
+	// Weight/Region, e.g. Docker's lb.weight/lb.region container labels
+	// verbatim.
+	Labels map[string]string
+}
+
+// Message is one Provider's complete, current view of the backends it
+// watches - a full desired-state snapshot, not a delta, mirroring how a
+// Traefik provider republishes its whole dynamic configuration on every
+// change rather than an incremental diff.
+type Message struct {
+	ProviderName string
+	Backends     []BackendSpec
+}
+
+// Provider watches one external configuration source. Provide starts the
+// watch and returns once it's established (or failed to establish);
+// further updates are delivered asynchronously on configChan until ctx is
+// canceled - the same non-blocking-start shape as
+// ConsulServiceManager.StartWatch.
+type Provider interface {
+	Provide(ctx context.Context, configChan chan<- Message) error
+}
+
+// Aggregator fans in every registered Provider's Message stream, merging
+// bursts that arrive within debounce of each other into a single update -
+// so e.g. a Kubernetes informer resync firing for several Endpoints in a
+// row produces one pool update instead of one per Endpoints object.
+type Aggregator struct {
+	debounce time.Duration
+}
+
+// NewAggregator returns an Aggregator that batches updates arriving within
+// debounce of each other. debounce <= 0 defaults to 500ms.
+func NewAggregator(debounce time.Duration) *Aggregator {
+	if debounce <= 0 {
+		debounce = 500 * time.Millisecond
+	}
+	return &Aggregator{debounce: debounce}
+}
+
+// Run starts every provider in providers (keyed by name, which is used only
+// in log and error messages; a Message's ProviderName is whatever its
+// provider set, and is not filled in from this name) and returns a channel
+// of debounced updates: each value is every provider's latest known
+// Message, keyed by ProviderName. The channel is closed once
+// ctx is canceled.
+func (a *Aggregator) Run(ctx context.Context, providers map[string]Provider) (<-chan map[string]Message, error) {
+	in := make(chan Message, 16)
+
+	for name, p := range providers {
+		if err := p.Provide(ctx, in); err != nil {
+			return nil, fmt.Errorf("provider %s: %w", name, err)
+		}
+		log.Printf("[INFO] dynamic config provider '%s' started", name)
+	}
+
+	out := make(chan map[string]Message, 1)
+	go a.debounceLoop(ctx, in, out)
+	return out, nil
+}
+
+// debounceLoop merges every Message arriving within a debounce of the
+// previous one in a burst - keyed by ProviderName, so one provider's update
+// replaces only its own latest state - and emits the merged set on out once
+// the burst goes quiet for a full debounce interval.
+func (a *Aggregator) debounceLoop(ctx context.Context, in <-chan Message, out chan<- map[string]Message) {
+	defer close(out)
+
+	latest := make(map[string]Message)
+	var timer *time.Timer
+	var fire <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg, ok := <-in:
+			if !ok {
+				return
+			}
+			latest[msg.ProviderName] = msg
+			if timer == nil {
+				timer = time.NewTimer(a.debounce)
+				fire = timer.C
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(a.debounce)
+			}
+
+		case <-fire:
+			snapshot := make(map[string]Message, len(latest))
+			for name, msg := range latest {
+				snapshot[name] = msg
+			}
+			select {
+			case out <- snapshot:
+			case <-ctx.Done():
+				return
+			}
+			timer = nil
+			fire = nil
+		}
+	}
+}
+
+// MergeBackends flattens every provider's Message into one URL-keyed map,
+// for callers (ServerPool.applyProviderMessages) that need the pool-wide
+// desired state rather than per-provider messages. Later providers in
+// iteration order win a URL collision; since map iteration order is
+// unspecified, providers shouldn't be configured to disagree about the
+// same backend URL.
+func MergeBackends(msgs map[string]Message) map[string]BackendSpec {
+	desired := make(map[string]BackendSpec)
+	for _, msg := range msgs {
+		for _, spec := range msg.Backends {
+			desired[spec.URL] = spec
+		}
+	}
+	return desired
+}
@@ -1,19 +1,32 @@
 package loadbalancer
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-type RateLimiter struct {
-	capacity   float64
-	refillRate float64
-	buckets    map[string]*tokenBucket
-	warmup     int
-	mux        sync.Mutex
+// RateLimiterStore is the pluggable token-bucket backend for RateLimiter.
+// TakeToken refills the bucket at key up to capacity at refill tokens/sec
+// based on elapsed time since its last write, then takes one token if
+// available, returning whether it was allowed and how many tokens remain.
+type RateLimiterStore interface {
+	TakeToken(ctx context.Context, key string, capacity, refill float64, now time.Time) (allowed bool, remaining float64, err error)
+}
+
+// memoryRateLimiterStore is the original in-process map+mutex implementation;
+// it's RateLimiter's default store, and the only one that makes sense for a
+// single-replica deployment.
+type memoryRateLimiterStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	warmup  int
 }
 
 type tokenBucket struct {
@@ -22,49 +35,170 @@ type tokenBucket struct {
 	warmup int
 }
 
-func NewRateLimiter(capacity int, refillPerSecond int) *RateLimiter {
-	return &RateLimiter{
-		capacity:   float64(capacity),
-		refillRate: float64(refillPerSecond),
-		buckets:    make(map[string]*tokenBucket),
-		warmup:     capacity * 3,
+func newMemoryRateLimiterStore(warmup int) *memoryRateLimiterStore {
+	return &memoryRateLimiterStore{
+		buckets: make(map[string]*tokenBucket),
+		warmup:  warmup,
 	}
 }
 
-func (rl *RateLimiter) Allow(key string) bool {
-	now := time.Now()
-	rl.mux.Lock()
-	bucket, ok := rl.buckets[key]
+func (s *memoryRateLimiterStore) TakeToken(ctx context.Context, key string, capacity, refill float64, now time.Time) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
 	if !ok {
-		bucket = &tokenBucket{tokens: rl.capacity, last: now, warmup: rl.warmup}
-		rl.buckets[key] = bucket
+		bucket = &tokenBucket{tokens: capacity, last: now, warmup: s.warmup}
+		s.buckets[key] = bucket
 	}
 	if bucket.warmup > 0 {
 		bucket.warmup--
 		bucket.last = now
-		rl.mux.Unlock()
-		return true
+		return true, bucket.tokens, nil
 	}
+
 	elapsed := now.Sub(bucket.last).Seconds()
 	if elapsed > 0 {
-		bucket.tokens += elapsed * rl.refillRate
-		if bucket.tokens > rl.capacity {
-			bucket.tokens = rl.capacity
+		bucket.tokens += elapsed * refill
+		if bucket.tokens > capacity {
+			bucket.tokens = capacity
 		}
 		bucket.last = now
 	}
+
 	allowed := bucket.tokens >= 1
 	if allowed {
 		bucket.tokens -= 1
 	}
-	rl.mux.Unlock()
+	return allowed, bucket.tokens, nil
+}
+
+// RedisRateLimiterStore backs RateLimiter with a distributed token bucket in
+// Redis (via RedisClient.TakeToken), so limits are enforced globally across
+// every load balancer replica instead of per-process.
+type RedisRateLimiterStore struct {
+	client    RedisClient
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// NewRedisRateLimiterStore returns a RedisRateLimiterStore keying every
+// bucket under keyPrefix. ttl bounds how long an idle bucket lingers in
+// Redis; pass 0 to derive it from each call's capacity/refill instead.
+func NewRedisRateLimiterStore(client RedisClient, keyPrefix string, ttl time.Duration) *RedisRateLimiterStore {
+	return &RedisRateLimiterStore{client: client, keyPrefix: keyPrefix, ttl: ttl}
+}
+
+func (s *RedisRateLimiterStore) TakeToken(ctx context.Context, key string, capacity, refill float64, now time.Time) (bool, float64, error) {
+	ttl := s.ttl
+	if ttl <= 0 && refill > 0 {
+		ttl = time.Duration(capacity/refill*2) * time.Second
+	}
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	return s.client.TakeToken(ctx, fmt.Sprintf("%s:ratelimit:%s", s.keyPrefix, key), capacity, refill, now, ttl)
+}
+
+// RateLimiter enforces a token-bucket limit per key, backed by a
+// RateLimiterStore (in-process by default; see NewRateLimiterWithStore for a
+// distributed Redis-backed one).
+type RateLimiter struct {
+	capacity   float64
+	refillRate float64
+	store      RateLimiterStore
+	logger     Logger
+	metrics    *LBMetrics
+	// keyType labels lb_rate_limited_total rejections from this limiter,
+	// e.g. "ip" (the default, matching clientKey's extraction) or a
+	// MultiLimiter rule's name.
+	keyType string
+}
+
+// NewRateLimiter returns a RateLimiter with capacity tokens refilling at
+// refillPerSecond tokens/sec, enforced in-process.
+func NewRateLimiter(capacity int, refillPerSecond int) *RateLimiter {
+	return &RateLimiter{
+		capacity:   float64(capacity),
+		refillRate: float64(refillPerSecond),
+		store:      newMemoryRateLimiterStore(capacity * 3),
+		logger:     NewNoopLogger(),
+		keyType:    "ip",
+	}
+}
+
+// NewRateLimiterWithStore returns a RateLimiter with capacity tokens
+// refilling at refillPerSecond tokens/sec, enforced through store - pass a
+// RedisRateLimiterStore to enforce the limit across every replica instead of
+// per-process.
+func NewRateLimiterWithStore(capacity int, refillPerSecond int, store RateLimiterStore) *RateLimiter {
+	return &RateLimiter{
+		capacity:   float64(capacity),
+		refillRate: float64(refillPerSecond),
+		store:      store,
+		logger:     NewNoopLogger(),
+		keyType:    "ip",
+	}
+}
+
+// EnableLogger attaches logger so rejections are logged with
+// {client_key, bucket_tokens, remaining} fields instead of just setting
+// response headers.
+func (rl *RateLimiter) EnableLogger(logger Logger) {
+	rl.logger = logger
+}
+
+// EnableMetrics attaches metrics so rejections increment
+// lb_rate_limited_total{key_type}. keyType labels which kind of key this
+// limiter enforces (e.g. "ip"); pass "" to keep the constructor's default.
+func (rl *RateLimiter) EnableMetrics(metrics *LBMetrics, keyType string) {
+	rl.metrics = metrics
+	if keyType != "" {
+		rl.keyType = keyType
+	}
+}
+
+// Allow reports whether key may proceed, failing open (allowing the
+// request) if the store errors - e.g. Redis being unreachable shouldn't take
+// the whole load balancer down with it.
+func (rl *RateLimiter) Allow(key string) bool {
+	allowed, _, err := rl.AllowToken(key)
+	if err != nil {
+		log.Printf("[ERROR] RateLimiter: store error for %s, failing open: %v", key, err)
+		return true
+	}
 	return allowed
 }
 
+// AllowToken is like Allow but also returns how many tokens remain in key's
+// bucket, for callers (Middleware, MultiLimiter) that surface
+// X-RateLimit-Remaining.
+func (rl *RateLimiter) AllowToken(key string) (allowed bool, remaining float64, err error) {
+	return rl.store.TakeToken(context.Background(), key, rl.capacity, rl.refillRate, time.Now())
+}
+
+// Middleware rejects a request with 429 once key's bucket is exhausted,
+// setting X-RateLimit-Remaining on every response and Retry-After on
+// rejection.
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		key := clientKey(r)
-		if !rl.Allow(key) {
+		allowed, remaining, err := rl.AllowToken(key)
+		if err != nil {
+			log.Printf("[ERROR] RateLimiter: store error for %s, failing open: %v", key, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatFloat(remaining, 'f', 0, 64))
+		if !allowed {
+			rl.logger.Warn("rate limit exceeded",
+				String("client_key", key),
+				Float64("bucket_tokens", rl.capacity),
+				Float64("remaining", remaining),
+			)
+			rl.metrics.RecordRateLimited(rl.keyType)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(rl.refillRate)))
 			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
 			return
 		}
@@ -72,6 +206,76 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// LimiterRule names one bucket MultiLimiter checks per request: Limiter
+// enforces the bucket, KeyFunc extracts its key (e.g. client IP, session
+// cookie, route prefix) from the request.
+type LimiterRule struct {
+	Name    string
+	Limiter *RateLimiter
+	KeyFunc func(r *http.Request) string
+}
+
+// MultiLimiter combines several independently-configured RateLimiters (e.g.
+// per-IP, per-session, and per-route) so a single request is checked against
+// all of them, rejecting as soon as any one bucket is exhausted.
+type MultiLimiter struct {
+	rules   []LimiterRule
+	metrics *LBMetrics
+}
+
+// NewMultiLimiter returns a MultiLimiter checking rules in order.
+func NewMultiLimiter(rules ...LimiterRule) *MultiLimiter {
+	return &MultiLimiter{rules: rules}
+}
+
+// EnableMetrics attaches metrics so a rejection increments
+// lb_rate_limited_total{key_type=<rule name>}.
+func (ml *MultiLimiter) EnableMetrics(metrics *LBMetrics) {
+	ml.metrics = metrics
+}
+
+// Middleware rejects a request with 429 as soon as any rule's bucket is
+// exhausted, setting X-RateLimit-Remaining (from whichever rule was checked
+// last) and, on rejection, Retry-After.
+func (ml *MultiLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var remaining float64
+		var refillRate float64
+
+		for _, rule := range ml.rules {
+			key := rule.KeyFunc(r)
+			allowed, ruleRemaining, err := rule.Limiter.AllowToken(key)
+			if err != nil {
+				log.Printf("[ERROR] MultiLimiter: rule %q store error for key %q, failing open: %v", rule.Name, key, err)
+				continue
+			}
+
+			remaining, refillRate = ruleRemaining, rule.Limiter.refillRate
+			if !allowed {
+				ml.metrics.RecordRateLimited(rule.Name)
+				w.Header().Set("X-RateLimit-Remaining", strconv.FormatFloat(remaining, 'f', 0, 64))
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(refillRate)))
+				http.Error(w, fmt.Sprintf("Too Many Requests (%s limit exceeded)", rule.Name), http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatFloat(remaining, 'f', 0, 64))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// retryAfterSeconds estimates how long until one more token is available, for
+// the Retry-After header on a rejection.
+func retryAfterSeconds(refillRate float64) int {
+	if refillRate <= 0 {
+		return 1
+	}
+	return int(1/refillRate) + 1
+}
+
+// clientKey extracts the rate-limiting key for a request: the first
+// X-Forwarded-For entry if present, otherwise RemoteAddr's host.
 func clientKey(r *http.Request) string {
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
 		parts := strings.Split(xff, ",")
@@ -85,3 +289,39 @@ func clientKey(r *http.Request) string {
 	}
 	return r.RemoteAddr
 }
+
+// ByClientIP is the default LimiterRule.KeyFunc: the same X-Forwarded-For/
+// RemoteAddr extraction RateLimiter.Middleware uses.
+func ByClientIP(r *http.Request) string {
+	return clientKey(r)
+}
+
+// ByCookie returns a LimiterRule.KeyFunc keying on cookie name's value (e.g.
+// a sticky-session cookie), for per-session limiting. Requests without the
+// cookie fall back to ByClientIP.
+func ByCookie(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if c, err := r.Cookie(name); err == nil && c.Value != "" {
+			return c.Value
+		}
+		return ByClientIP(r)
+	}
+}
+
+// ByPathPrefix returns a LimiterRule.KeyFunc keying on the longest of
+// prefixes matching the request path, for per-route limiting. Requests
+// matching no prefix all share one "default" bucket.
+func ByPathPrefix(prefixes ...string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		longest := ""
+		for _, p := range prefixes {
+			if strings.HasPrefix(r.URL.Path, p) && len(p) > len(longest) {
+				longest = p
+			}
+		}
+		if longest == "" {
+			return "default"
+		}
+		return longest
+	}
+}
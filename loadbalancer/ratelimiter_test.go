@@ -0,0 +1,91 @@
+package loadbalancer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimiterStoreExhaustsCapacity(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryRateLimiterStore(0)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		allowed, remaining, err := store.TakeToken(ctx, "k", 3, 1, now)
+		if err != nil || !allowed {
+			t.Fatalf("expected token %d to be allowed, got allowed=%v err=%v", i, allowed, err)
+		}
+		if remaining != float64(3-i-1) {
+			t.Fatalf("expected %v tokens remaining after take %d, got %v", float64(3-i-1), i, remaining)
+		}
+	}
+
+	allowed, remaining, err := store.TakeToken(ctx, "k", 3, 1, now)
+	if err != nil || allowed {
+		t.Fatalf("expected bucket to be exhausted, got allowed=%v remaining=%v err=%v", allowed, remaining, err)
+	}
+}
+
+func TestMemoryRateLimiterStoreRefillsOverTime(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryRateLimiterStore(0)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if allowed, _, err := store.TakeToken(ctx, "k", 3, 1, now); err != nil || !allowed {
+			t.Fatalf("expected token %d to be allowed, got allowed=%v err=%v", i, allowed, err)
+		}
+	}
+
+	later := now.Add(2 * time.Second)
+	allowed, remaining, err := store.TakeToken(ctx, "k", 3, 1, later)
+	if err != nil || !allowed {
+		t.Fatalf("expected a token to have refilled after 2s at 1/s, got allowed=%v err=%v", allowed, err)
+	}
+	if remaining != 1 {
+		t.Fatalf("expected 1 token remaining (2 refilled, 1 taken), got %v", remaining)
+	}
+}
+
+func TestMemoryRateLimiterStoreRefillCapsAtCapacity(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryRateLimiterStore(0)
+	now := time.Now()
+
+	if _, _, err := store.TakeToken(ctx, "k", 3, 1, now); err != nil {
+		t.Fatalf("TakeToken failed: %v", err)
+	}
+
+	later := now.Add(time.Hour)
+	allowed, remaining, err := store.TakeToken(ctx, "k", 3, 1, later)
+	if err != nil || !allowed {
+		t.Fatalf("expected token to be allowed after a long idle period, got allowed=%v err=%v", allowed, err)
+	}
+	if remaining != 2 {
+		t.Fatalf("expected refill to cap at capacity (3 - 1 taken = 2), got %v", remaining)
+	}
+}
+
+func TestMemoryRateLimiterStoreWarmupBypassesLimit(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryRateLimiterStore(2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := store.TakeToken(ctx, "k", 1, 1, now)
+		if err != nil || !allowed {
+			t.Fatalf("expected warmup take %d to be allowed without consuming tokens, got allowed=%v err=%v", i, allowed, err)
+		}
+	}
+
+	// Warmup exhausted; the bucket still has its full capacity since warmup
+	// takes don't deduct tokens, so the very next take should still succeed.
+	allowed, remaining, err := store.TakeToken(ctx, "k", 1, 1, now)
+	if err != nil || !allowed {
+		t.Fatalf("expected first post-warmup take to be allowed, got allowed=%v err=%v", allowed, err)
+	}
+	if remaining != 0 {
+		t.Fatalf("expected 0 tokens remaining after the first post-warmup take, got %v", remaining)
+	}
+}
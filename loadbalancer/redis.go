@@ -3,12 +3,22 @@ package loadbalancer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// ErrStaleFencingToken is returned by ShouldScale when the caller's Leader
+// token is older than the fencing token already recorded in AutoScalingState,
+// meaning leadership has moved on since the caller was elected.
+var ErrStaleFencingToken = errors.New("autoscaling: stale fencing token")
+
 // RedisClient interface defines the operations needed for distributed sessions
 type RedisClient interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
@@ -16,13 +26,59 @@ type RedisClient interface {
 	Del(ctx context.Context, keys ...string) error
 	Exists(ctx context.Context, keys ...string) (int64, error)
 	Expire(ctx context.Context, key string, expiration time.Duration) error
+	// SetNX sets key to value with expiration only if key does not already
+	// exist (Redis SET NX PX), reporting whether the set happened. Leader
+	// uses it to acquire its lease.
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+	// Publish sends payload to every current subscriber of topic, mirroring
+	// Redis PUBLISH. ClusterBus uses it to fan control-plane events out to
+	// every replica.
+	Publish(ctx context.Context, topic string, payload string) error
+	// Subscribe registers handler to be called with the payload of every
+	// message published to topic from the point Subscribe is called onward,
+	// mirroring Redis SUBSCRIBE. It blocks until ctx is canceled, so callers
+	// run it in its own goroutine.
+	Subscribe(ctx context.Context, topic string, handler func(payload string)) error
+	// TakeToken atomically refills and takes one token from the bucket
+	// stored at key: tokens = min(capacity, tokens + elapsed*refill) - 1,
+	// where elapsed is the time since the bucket's last write. It reports
+	// whether a token was available and how many remain, and is the single
+	// primitive RedisRateLimiterStore needs; GoRedisClient runs it as a Lua
+	// script so concurrent replicas never race on the read-modify-write,
+	// the same way SetNX gives Leader an atomic compare-and-swap. ttl bounds
+	// how long an idle bucket lingers in Redis.
+	TakeToken(ctx context.Context, key string, capacity, refill float64, now time.Time, ttl time.Duration) (allowed bool, remaining float64, err error)
+	// IncrBy atomically adds delta to the integer stored at key (creating it
+	// as delta if absent) and returns the new value, mirroring Redis INCRBY.
+	// DrainController uses it to keep per-backend sticky-session counts
+	// consistent across replicas.
+	IncrBy(ctx context.Context, key string, delta int64) (int64, error)
 }
 
 // StickySessionManager manages sticky sessions using Redis
 type StickySessionManager struct {
-	redisClient  RedisClient
-	keyPrefix    string
-	sessionTTL   time.Duration
+	redisClient RedisClient
+	keyPrefix   string
+	sessionTTL  time.Duration
+
+	// breaker guards every Redis call made by this manager. It's nil until
+	// EnableCircuitBreaker/EnableAsyncWrites is called; while it's nil, calls
+	// run unguarded (the original behavior).
+	breaker *Tracker
+	// localCache holds the last known SessionData per sessionID so GetSession
+	// can serve a read when Redis is unavailable or the breaker is open.
+	localCache sync.Map // sessionID -> *SessionData
+
+	asyncQueue     chan sessionWriteJob
+	queueHighWater int
+	droppedWrites  prometheus.Counter
+}
+
+// sessionWriteJob is a pending SetSession/updateSession write processed by the
+// async worker pool started by EnableAsyncWrites.
+type sessionWriteJob struct {
+	sessionID string
+	session   *SessionData
 }
 
 // SessionData represents the data stored for a sticky session
@@ -42,11 +98,96 @@ func NewStickySessionManager(redisClient RedisClient, keyPrefix string, sessionT
 	}
 }
 
-// GetSession retrieves session data from Redis
+// EnableCircuitBreaker wraps every Redis call this manager makes in a
+// circuit breaker, so a struggling Redis stops adding latency to every
+// request once it trips.
+func (sm *StickySessionManager) EnableCircuitBreaker(opts ...CircuitBreakerOption) {
+	sm.breaker = NewTracker(fmt.Sprintf("%s:sticky-sessions", sm.keyPrefix), opts...)
+}
+
+// EnableAsyncWrites puts SetSession/updateSession into async mode: writes are
+// enqueued onto a bounded worker pool instead of blocking the request path on
+// Redis. droppedWrites (may be nil) is incremented whenever a write is
+// dropped because the breaker is open or the queue is full. readyToTrip on
+// the breaker additionally fires once the queue backlog passes
+// highWaterMark, on top of whatever failure-rate rule opts configures --
+// callers typically pass SlidingWindowReadyToTrip so the breaker also trips
+// on a high Redis error rate.
+func (sm *StickySessionManager) EnableAsyncWrites(queueSize, workers, highWaterMark int, droppedWrites prometheus.Counter, opts ...CircuitBreakerOption) {
+	sm.queueHighWater = highWaterMark
+	sm.droppedWrites = droppedWrites
+	sm.asyncQueue = make(chan sessionWriteJob, queueSize)
+
+	if sm.breaker == nil {
+		sm.EnableCircuitBreaker(opts...)
+	}
+	baseReadyToTrip := sm.breaker.readyToTrip
+	sm.breaker.readyToTrip = func(counts Counts) bool {
+		if len(sm.asyncQueue) > sm.queueHighWater {
+			return true
+		}
+		return baseReadyToTrip(counts)
+	}
+
+	for i := 0; i < workers; i++ {
+		go sm.runAsyncWorker()
+	}
+}
+
+func (sm *StickySessionManager) runAsyncWorker() {
+	for job := range sm.asyncQueue {
+		if err := sm.writeThrough(context.Background(), job.sessionID, job.session); err != nil {
+			log.Printf("[WARN] Async session write for %s failed: %v", job.sessionID, err)
+		}
+	}
+}
+
+// writeThrough performs the actual Redis Set, guarded by the breaker when one
+// is configured, and keeps localCache up to date regardless of outcome so
+// reads have a fallback.
+func (sm *StickySessionManager) writeThrough(ctx context.Context, sessionID string, session *SessionData) error {
+	sm.localCache.Store(sessionID, session)
+
+	key := sm.sessionKey(sessionID)
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session data: %w", err)
+	}
+
+	if sm.breaker == nil {
+		return sm.redisClient.Set(ctx, key, data, sm.sessionTTL)
+	}
+	_, err = sm.breaker.Execute(func() (any, error) {
+		return nil, sm.redisClient.Set(ctx, key, data, sm.sessionTTL)
+	})
+	return err
+}
+
+// GetSession retrieves session data from Redis, guarded by the circuit
+// breaker when one is configured. On a Redis error or an open breaker it
+// falls back to the local in-memory cache populated by prior reads/writes.
 func (sm *StickySessionManager) GetSession(ctx context.Context, sessionID string) (*SessionData, error) {
 	key := sm.sessionKey(sessionID)
-	data, err := sm.redisClient.Get(ctx, key)
+
+	var data string
+	var err error
+	if sm.breaker == nil {
+		data, err = sm.redisClient.Get(ctx, key)
+	} else {
+		var result any
+		result, err = sm.breaker.Execute(func() (any, error) {
+			return sm.redisClient.Get(ctx, key)
+		})
+		if s, ok := result.(string); ok {
+			data = s
+		}
+	}
+
 	if err != nil {
+		if cached, ok := sm.localCache.Load(sessionID); ok {
+			log.Printf("[WARN] Redis unavailable for session %s, serving from local cache: %v", sessionID, err)
+			return cached.(*SessionData), nil
+		}
 		return nil, fmt.Errorf("failed to get session from Redis: %w", err)
 	}
 	if data == "" {
@@ -58,6 +199,8 @@ func (sm *StickySessionManager) GetSession(ctx context.Context, sessionID string
 		return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
 	}
 
+	sm.localCache.Store(sessionID, &session)
+
 	// Update last access time
 	session.LastAccess = time.Now()
 	if err := sm.updateSession(ctx, sessionID, &session); err != nil {
@@ -67,7 +210,9 @@ func (sm *StickySessionManager) GetSession(ctx context.Context, sessionID string
 	return &session, nil
 }
 
-// SetSession stores session data in Redis
+// SetSession stores session data in Redis. In async mode (EnableAsyncWrites)
+// this enqueues the write and returns immediately; a full queue or an open
+// breaker drops the write and increments droppedWrites rather than blocking.
 func (sm *StickySessionManager) SetSession(ctx context.Context, sessionID string, backendURL, region string) error {
 	session := &SessionData{
 		BackendURL: backendURL,
@@ -75,22 +220,47 @@ func (sm *StickySessionManager) SetSession(ctx context.Context, sessionID string
 		CreatedAt:  time.Now(),
 		LastAccess: time.Now(),
 	}
-
-	key := sm.sessionKey(sessionID)
-	data, err := json.Marshal(session)
-	if err != nil {
-		return fmt.Errorf("failed to marshal session data: %w", err)
-	}
-
-	return sm.redisClient.Set(ctx, key, data, sm.sessionTTL)
+	return sm.writeSession(ctx, sessionID, session)
 }
 
 // DeleteSession removes a session from Redis
 func (sm *StickySessionManager) DeleteSession(ctx context.Context, sessionID string) error {
+	sm.localCache.Delete(sessionID)
 	key := sm.sessionKey(sessionID)
 	return sm.redisClient.Del(ctx, key)
 }
 
+// InvalidateBackend evicts every localCache entry pointing at backendURL.
+// Called when a backend drains, either locally or via a ClusterBus
+// SessionInvalidateEvent from a peer, so a stale cache entry can't keep
+// routing sticky traffic to a backend that's gone.
+func (sm *StickySessionManager) InvalidateBackend(backendURL string) {
+	sm.localCache.Range(func(key, value any) bool {
+		if session, ok := value.(*SessionData); ok && session.BackendURL == backendURL {
+			sm.localCache.Delete(key)
+		}
+		return true
+	})
+}
+
+// SessionIDsForBackend returns the session IDs in localCache currently
+// assigned to backendURL - this replica's own view of sessions it has
+// served, populated by every GetSession call regardless of whether the
+// session was found locally or in Redis (see GetSession). EvictSession
+// draws on it when ServerPool's own stickySessions fallback map comes up
+// short, e.g. on a replica that mostly serves sessions found in Redis
+// rather than ones it assigned itself.
+func (sm *StickySessionManager) SessionIDsForBackend(backendURL string) []string {
+	var ids []string
+	sm.localCache.Range(func(key, value any) bool {
+		if session, ok := value.(*SessionData); ok && session.BackendURL == backendURL {
+			ids = append(ids, key.(string))
+		}
+		return true
+	})
+	return ids
+}
+
 // SessionExists checks if a session exists
 func (sm *StickySessionManager) SessionExists(ctx context.Context, sessionID string) (bool, error) {
 	key := sm.sessionKey(sessionID)
@@ -103,12 +273,36 @@ func (sm *StickySessionManager) SessionExists(ctx context.Context, sessionID str
 
 // updateSession updates session data in Redis
 func (sm *StickySessionManager) updateSession(ctx context.Context, sessionID string, session *SessionData) error {
-	key := sm.sessionKey(sessionID)
-	data, err := json.Marshal(session)
-	if err != nil {
-		return fmt.Errorf("failed to marshal session data: %w", err)
+	return sm.writeSession(ctx, sessionID, session)
+}
+
+// writeSession routes a session write either onto the async queue (if
+// EnableAsyncWrites was called) or straight through to Redis.
+func (sm *StickySessionManager) writeSession(ctx context.Context, sessionID string, session *SessionData) error {
+	if sm.asyncQueue == nil {
+		return sm.writeThrough(ctx, sessionID, session)
+	}
+
+	if sm.breaker != nil && sm.breaker.State() == StateOpen {
+		sm.dropWrite(sessionID)
+		return nil
+	}
+
+	select {
+	case sm.asyncQueue <- sessionWriteJob{sessionID: sessionID, session: session}:
+		sm.localCache.Store(sessionID, session)
+		return nil
+	default:
+		sm.dropWrite(sessionID)
+		return nil
+	}
+}
+
+func (sm *StickySessionManager) dropWrite(sessionID string) {
+	if sm.droppedWrites != nil {
+		sm.droppedWrites.Inc()
 	}
-	return sm.redisClient.Set(ctx, key, data, sm.sessionTTL)
+	log.Printf("[WARN] Dropped async session write for %s: queue backed up or breaker open", sessionID)
 }
 
 // sessionKey generates the Redis key for a session
@@ -118,12 +312,17 @@ func (sm *StickySessionManager) sessionKey(sessionID string) string {
 
 // AutoScalingState represents the distributed state for auto-scaling
 type AutoScalingState struct {
-	CurrentReplicas int     `json:"current_replicas"`
-	TotalRequests    int64   `json:"total_requests"`
-	AverageLatency   float64 `json:"average_latency"`
-	LastScaleUp      time.Time `json:"last_scale_up"`
-	LastScaleDown    time.Time `json:"last_scale_down"`
-	CooldownPeriod   time.Duration `json:"cooldown_period"`
+	CurrentReplicas int           `json:"current_replicas"`
+	TotalRequests   int64         `json:"total_requests"`
+	AverageLatency  float64       `json:"average_latency"`
+	LastScaleUp     time.Time     `json:"last_scale_up"`
+	LastScaleDown   time.Time     `json:"last_scale_down"`
+	CooldownPeriod  time.Duration `json:"cooldown_period"`
+	// FencingToken is stamped with the Leader token of whichever replica last
+	// wrote this state via ShouldScale, so a replica that has since lost
+	// leadership (and whose token is therefore stale) gets rejected instead
+	// of clobbering a newer leader's write.
+	FencingToken int64 `json:"fencing_token"`
 }
 
 // AutoScalingStateManager manages auto-scaling state using Redis
@@ -131,6 +330,11 @@ type AutoScalingStateManager struct {
 	redisClient RedisClient
 	keyPrefix   string
 	stateTTL    time.Duration
+
+	// breaker guards Redis calls made by this manager, mirroring
+	// StickySessionManager.breaker. Nil until EnableCircuitBreaker is called.
+	breaker     *Tracker
+	cachedState *AutoScalingState
 }
 
 // NewAutoScalingStateManager creates a new Redis-based auto-scaling state manager
@@ -142,11 +346,36 @@ func NewAutoScalingStateManager(redisClient RedisClient, keyPrefix string, state
 	}
 }
 
-// GetAutoScalingState retrieves the current auto-scaling state
+// EnableCircuitBreaker wraps every Redis call this manager makes in a circuit
+// breaker, mirroring StickySessionManager.EnableCircuitBreaker.
+func (asm *AutoScalingStateManager) EnableCircuitBreaker(opts ...CircuitBreakerOption) {
+	asm.breaker = NewTracker(fmt.Sprintf("%s:autoscaling-state", asm.keyPrefix), opts...)
+}
+
+// GetAutoScalingState retrieves the current auto-scaling state, guarded by
+// the circuit breaker when one is configured. On a Redis error or an open
+// breaker it falls back to the last successfully read/written state.
 func (asm *AutoScalingStateManager) GetAutoScalingState(ctx context.Context) (*AutoScalingState, error) {
 	key := asm.stateKey()
-	data, err := asm.redisClient.Get(ctx, key)
+
+	var data string
+	var err error
+	if asm.breaker == nil {
+		data, err = asm.redisClient.Get(ctx, key)
+	} else {
+		var result any
+		result, err = asm.breaker.Execute(func() (any, error) {
+			return asm.redisClient.Get(ctx, key)
+		})
+		if s, ok := result.(string); ok {
+			data = s
+		}
+	}
 	if err != nil {
+		if asm.cachedState != nil {
+			log.Printf("[WARN] Redis unavailable for auto-scaling state, serving from local cache: %v", err)
+			return asm.cachedState, nil
+		}
 		return nil, fmt.Errorf("failed to get auto-scaling state from Redis: %w", err)
 	}
 	if data == "" {
@@ -169,14 +398,24 @@ func (asm *AutoScalingStateManager) GetAutoScalingState(ctx context.Context) (*A
 	return &state, nil
 }
 
-// SetAutoScalingState stores the auto-scaling state in Redis
+// SetAutoScalingState stores the auto-scaling state in Redis, guarded by the
+// circuit breaker when one is configured.
 func (asm *AutoScalingStateManager) SetAutoScalingState(ctx context.Context, state *AutoScalingState) error {
+	asm.cachedState = state
+
 	key := asm.stateKey()
 	data, err := json.Marshal(state)
 	if err != nil {
 		return fmt.Errorf("failed to marshal auto-scaling state: %w", err)
 	}
-	return asm.redisClient.Set(ctx, key, data, asm.stateTTL)
+
+	if asm.breaker == nil {
+		return asm.redisClient.Set(ctx, key, data, asm.stateTTL)
+	}
+	_, err = asm.breaker.Execute(func() (any, error) {
+		return nil, asm.redisClient.Set(ctx, key, data, asm.stateTTL)
+	})
+	return err
 }
 
 // UpdateRequestCount increments the total request count
@@ -185,25 +424,39 @@ func (asm *AutoScalingStateManager) UpdateRequestCount(ctx context.Context, requ
 	if err != nil {
 		return err
 	}
-	
+
 	state.TotalRequests = requestCount
 	return asm.SetAutoScalingState(ctx, state)
 }
 
-// ShouldScale determines if auto-scaling should occur based on request count
-func (asm *AutoScalingStateManager) ShouldScale(ctx context.Context, requestCount int64, threshold int64) (bool, bool, error) {
+// ShouldScale determines if auto-scaling should occur based on request count.
+// token must be the fencing token of the Leader lease the caller currently
+// holds; only the elected leader should call ShouldScale (see Leader), and
+// this rejects writes from a replica whose lease has since been superseded.
+func (asm *AutoScalingStateManager) ShouldScale(ctx context.Context, requestCount int64, threshold int64, token int64) (bool, bool, error) {
 	state, err := asm.GetAutoScalingState(ctx)
 	if err != nil {
 		return false, false, err
 	}
+	if token < state.FencingToken {
+		return false, false, fmt.Errorf("%w: token %d is stale against current %d", ErrStaleFencingToken, token, state.FencingToken)
+	}
+	if token > state.FencingToken {
+		// Record the newer token even when no scaling happens below, so a
+		// superseded leader's later call is rejected.
+		state.FencingToken = token
+		if err := asm.SetAutoScalingState(ctx, state); err != nil {
+			return false, false, fmt.Errorf("failed to record fencing token: %w", err)
+		}
+	}
 
 	now := time.Now()
 	scaleUp := false
 	scaleDown := false
 
 	// Check if we should scale up
-	if requestCount > threshold && 
-	   (state.LastScaleUp.IsZero() || now.Sub(state.LastScaleUp) > state.CooldownPeriod) {
+	if requestCount > threshold &&
+		(state.LastScaleUp.IsZero() || now.Sub(state.LastScaleUp) > state.CooldownPeriod) {
 		scaleUp = true
 		state.LastScaleUp = now
 		state.CurrentReplicas++
@@ -212,7 +465,7 @@ func (asm *AutoScalingStateManager) ShouldScale(ctx context.Context, requestCoun
 
 	// Check if we should scale down
 	if requestCount < threshold/2 && requestCount > 0 && state.CurrentReplicas > 1 &&
-	   (state.LastScaleDown.IsZero() || now.Sub(state.LastScaleDown) > state.CooldownPeriod) {
+		(state.LastScaleDown.IsZero() || now.Sub(state.LastScaleDown) > state.CooldownPeriod) {
 		scaleDown = true
 		state.LastScaleDown = now
 		state.CurrentReplicas--
@@ -233,38 +486,115 @@ func (asm *AutoScalingStateManager) stateKey() string {
 	return fmt.Sprintf("%s:autoscaling:state", asm.keyPrefix)
 }
 
-// MockRedisClient implements a simple in-memory Redis client for testing
+// localPubSub implements Publish/Subscribe as simple in-process fan-out, for
+// RedisClient implementations with no real cross-process transport of their
+// own (MockRedisClient, and the single-node SessionStore backends in
+// sessionstore.go). Embed it to satisfy that part of the interface.
+type localPubSub struct {
+	mu          sync.Mutex
+	subscribers map[string][]func(string)
+}
+
+// Publish calls every handler currently subscribed to topic, in the calling
+// goroutine.
+func (p *localPubSub) Publish(ctx context.Context, topic string, payload string) error {
+	p.mu.Lock()
+	handlers := append([]func(string){}, p.subscribers[topic]...)
+	p.mu.Unlock()
+	for _, h := range handlers {
+		h(payload)
+	}
+	return nil
+}
+
+// Subscribe registers handler for topic and blocks until ctx is canceled.
+func (p *localPubSub) Subscribe(ctx context.Context, topic string, handler func(payload string)) error {
+	p.mu.Lock()
+	if p.subscribers == nil {
+		p.subscribers = make(map[string][]func(string))
+	}
+	p.subscribers[topic] = append(p.subscribers[topic], handler)
+	p.mu.Unlock()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// MockRedisClient implements a simple in-memory Redis client for testing.
+// Unlike earlier versions it honors expiration, since Leader's lease logic
+// depends on keys actually going away.
 type MockRedisClient struct {
-	data map[string]string
-	mu   sync.RWMutex
+	localPubSub
+	data    map[string]string
+	expires map[string]time.Time // key -> expiry, absent/zero means no TTL
+	mu      sync.RWMutex
 }
 
 // NewMockRedisClient creates a new mock Redis client
 func NewMockRedisClient() *MockRedisClient {
 	return &MockRedisClient{
-		data: make(map[string]string),
+		data:    make(map[string]string),
+		expires: make(map[string]time.Time),
 	}
 }
 
-// Set stores a key-value pair with expiration
-func (m *MockRedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+func (m *MockRedisClient) valueString(value interface{}) string {
 	switch v := value.(type) {
 	case []byte:
-		m.data[key] = string(v)
+		return string(v)
 	case string:
-		m.data[key] = v
+		return v
 	default:
-		m.data[key] = fmt.Sprintf("%v", value)
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// expiredLocked reports whether key has an expiry in the past. Callers must
+// hold m.mu.
+func (m *MockRedisClient) expiredLocked(key string) bool {
+	expiry, ok := m.expires[key]
+	return ok && !expiry.IsZero() && time.Now().After(expiry)
+}
+
+// Set stores a key-value pair with expiration
+func (m *MockRedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = m.valueString(value)
+	if expiration > 0 {
+		m.expires[key] = time.Now().Add(expiration)
+	} else {
+		delete(m.expires, key)
 	}
 	return nil
 }
 
+// SetNX sets key to value with expiration only if key does not already exist
+// or has expired, mirroring Redis's SET NX PX.
+func (m *MockRedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.data[key]; exists && !m.expiredLocked(key) {
+		return false, nil
+	}
+
+	m.data[key] = m.valueString(value)
+	if expiration > 0 {
+		m.expires[key] = time.Now().Add(expiration)
+	} else {
+		delete(m.expires, key)
+	}
+	return true, nil
+}
+
 // Get retrieves a value by key
 func (m *MockRedisClient) Get(ctx context.Context, key string) (string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	if m.expiredLocked(key) {
+		return "", nil
+	}
 	return m.data[key], nil
 }
 
@@ -274,26 +604,101 @@ func (m *MockRedisClient) Del(ctx context.Context, keys ...string) error {
 	defer m.mu.Unlock()
 	for _, key := range keys {
 		delete(m.data, key)
+		delete(m.expires, key)
 	}
 	return nil
 }
 
-// Exists checks if keys exist
+// Exists checks if keys exist and are unexpired
 func (m *MockRedisClient) Exists(ctx context.Context, keys ...string) (int64, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	count := int64(0)
 	for _, key := range keys {
-		if _, exists := m.data[key]; exists {
+		if _, exists := m.data[key]; exists && !m.expiredLocked(key) {
 			count++
 		}
 	}
 	return count, nil
 }
 
-
-// Expire sets the expiration time for a key
+// Expire sets the expiration time for a key (Redis PEXPIRE semantics: the
+// full duration is kept, so sub-second expiries work). A missing key is a
+// no-op.
 func (m *MockRedisClient) Expire(ctx context.Context, key string, expiration time.Duration) error {
-	// Mock implementation - in a real Redis client, this would set TTL
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.data[key]; !exists {
+		return nil
+	}
+	m.expires[key] = time.Now().Add(expiration)
 	return nil
 }
+
+// IncrBy atomically adds delta to the integer stored at key, mirroring
+// Redis INCRBY.
+func (m *MockRedisClient) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current int64
+	if raw, exists := m.data[key]; exists && !m.expiredLocked(key) {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("mock redis: value for %s is not an integer: %w", key, err)
+		}
+		current = parsed
+	}
+
+	current += delta
+	m.data[key] = strconv.FormatInt(current, 10)
+	return current, nil
+}
+
+// mockTokenBucket is the JSON shape MockRedisClient.TakeToken stores under
+// key, mirroring the {tokens, last_ts} hash GoRedisClient's Lua script keeps
+// in real Redis.
+type mockTokenBucket struct {
+	Tokens float64   `json:"tokens"`
+	LastTS time.Time `json:"last_ts"`
+}
+
+// TakeToken implements the same tokens = min(capacity, tokens +
+// elapsed*refill) - 1 logic as GoRedisClient's Lua script, but as a plain Go
+// read-modify-write guarded by m.mu - MockRedisClient has no concurrent
+// replicas to race against, so it doesn't need Redis's atomicity guarantees.
+func (m *MockRedisClient) TakeToken(ctx context.Context, key string, capacity, refill float64, now time.Time, ttl time.Duration) (bool, float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var bucket mockTokenBucket
+	if raw, exists := m.data[key]; exists && !m.expiredLocked(key) {
+		if err := json.Unmarshal([]byte(raw), &bucket); err != nil {
+			return false, 0, fmt.Errorf("mock redis: corrupt token bucket for %s: %w", key, err)
+		}
+	} else {
+		bucket = mockTokenBucket{Tokens: capacity, LastTS: now}
+	}
+
+	elapsed := now.Sub(bucket.LastTS).Seconds()
+	if elapsed > 0 {
+		bucket.Tokens = math.Min(capacity, bucket.Tokens+elapsed*refill)
+	}
+	bucket.LastTS = now
+
+	allowed := bucket.Tokens >= 1
+	if allowed {
+		bucket.Tokens--
+	}
+
+	data, err := json.Marshal(bucket)
+	if err != nil {
+		return false, 0, fmt.Errorf("mock redis: failed to marshal token bucket for %s: %w", key, err)
+	}
+	m.data[key] = string(data)
+	if ttl > 0 {
+		m.expires[key] = now.Add(ttl)
+	}
+
+	return allowed, bucket.Tokens, nil
+}
@@ -0,0 +1,287 @@
+package loadbalancer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisMode selects which go-redis client topology GoRedisClient dials.
+type RedisMode string
+
+const (
+	RedisModeStandalone RedisMode = "standalone"
+	RedisModeSentinel   RedisMode = "sentinel"
+	RedisModeCluster    RedisMode = "cluster"
+)
+
+// RedisConfig configures the production Redis client, covering standalone,
+// Sentinel, and Cluster topologies plus TLS and pooling. It is typically
+// parsed from YAML or populated via LoadRedisConfigFromEnv.
+type RedisConfig struct {
+	Mode         RedisMode     `yaml:"mode"`
+	Addrs        []string      `yaml:"addrs"`
+	MasterName   string        `yaml:"master_name"` // required for RedisModeSentinel
+	Username     string        `yaml:"username"`
+	Password     string        `yaml:"password"`
+	DB           int           `yaml:"db"` // ignored in cluster mode
+	TLSEnabled   bool          `yaml:"tls_enabled"`
+	PoolSize     int           `yaml:"pool_size"`
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+}
+
+// LoadRedisConfigFromEnv builds a RedisConfig from env vars:
+// REDIS_MODE=standalone|sentinel|cluster, REDIS_ADDRS (comma-separated),
+// REDIS_MASTER_NAME, REDIS_USERNAME, REDIS_PASSWORD, REDIS_DB,
+// REDIS_TLS_ENABLED=true|false, REDIS_POOL_SIZE.
+func LoadRedisConfigFromEnv() RedisConfig {
+	cfg := RedisConfig{
+		Mode:         RedisMode(strings.ToLower(os.Getenv("REDIS_MODE"))),
+		MasterName:   os.Getenv("REDIS_MASTER_NAME"),
+		Username:     os.Getenv("REDIS_USERNAME"),
+		Password:     os.Getenv("REDIS_PASSWORD"),
+		PoolSize:     10,
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  3 * time.Second,
+		WriteTimeout: 3 * time.Second,
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = RedisModeStandalone
+	}
+	if addrs := os.Getenv("REDIS_ADDRS"); addrs != "" {
+		cfg.Addrs = strings.Split(addrs, ",")
+		for i := range cfg.Addrs {
+			cfg.Addrs[i] = strings.TrimSpace(cfg.Addrs[i])
+		}
+	} else {
+		cfg.Addrs = []string{"localhost:6379"}
+	}
+	if db, err := strconv.Atoi(os.Getenv("REDIS_DB")); err == nil {
+		cfg.DB = db
+	}
+	if poolSize, err := strconv.Atoi(os.Getenv("REDIS_POOL_SIZE")); err == nil {
+		cfg.PoolSize = poolSize
+	}
+	cfg.TLSEnabled, _ = strconv.ParseBool(os.Getenv("REDIS_TLS_ENABLED"))
+	return cfg
+}
+
+// GoRedisClient adapts github.com/redis/go-redis/v9 to the RedisClient
+// interface, transparently dialing a standalone node, a Sentinel-managed
+// failover group, or a Cluster depending on RedisConfig.Mode.
+type GoRedisClient struct {
+	client redis.UniversalClient
+}
+
+// NewGoRedisClient builds a GoRedisClient for the given RedisConfig. Standalone
+// and Sentinel configs share redis.UniversalOptions; Cluster mode is split out
+// because go-redis exposes it as a distinct constructor.
+func NewGoRedisClient(cfg RedisConfig) (*GoRedisClient, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, fmt.Errorf("redis: at least one address is required")
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.TLSEnabled {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	switch cfg.Mode {
+	case RedisModeCluster:
+		client := redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.Addrs,
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			PoolSize:     cfg.PoolSize,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			TLSConfig:    tlsConfig,
+		})
+		return &GoRedisClient{client: client}, nil
+	case RedisModeSentinel:
+		if cfg.MasterName == "" {
+			return nil, fmt.Errorf("redis: master_name is required in sentinel mode")
+		}
+		client := redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    cfg.MasterName,
+			SentinelAddrs: cfg.Addrs,
+			Username:      cfg.Username,
+			Password:      cfg.Password,
+			DB:            cfg.DB,
+			PoolSize:      cfg.PoolSize,
+			DialTimeout:   cfg.DialTimeout,
+			ReadTimeout:   cfg.ReadTimeout,
+			WriteTimeout:  cfg.WriteTimeout,
+			TLSConfig:     tlsConfig,
+		})
+		return &GoRedisClient{client: client}, nil
+	case RedisModeStandalone, "":
+		client := redis.NewClient(&redis.Options{
+			Addr:         cfg.Addrs[0],
+			Username:     cfg.Username,
+			Password:     cfg.Password,
+			DB:           cfg.DB,
+			PoolSize:     cfg.PoolSize,
+			DialTimeout:  cfg.DialTimeout,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			TLSConfig:    tlsConfig,
+		})
+		return &GoRedisClient{client: client}, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown mode %q", cfg.Mode)
+	}
+}
+
+// Set stores a key-value pair with expiration.
+func (c *GoRedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return c.client.Set(ctx, key, value, expiration).Err()
+}
+
+// Get retrieves a value by key. A missing key returns "" with no error, matching
+// the RedisClient contract relied on by StickySessionManager.
+func (c *GoRedisClient) Get(ctx context.Context, key string) (string, error) {
+	val, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+// SetNX sets key to value with expiration only if key does not already exist,
+// mirroring Redis SET NX PX. Leader relies on this to acquire its lease
+// atomically.
+func (c *GoRedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, expiration).Result()
+}
+
+// Del deletes keys.
+func (c *GoRedisClient) Del(ctx context.Context, keys ...string) error {
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// Exists checks how many of the given keys exist.
+func (c *GoRedisClient) Exists(ctx context.Context, keys ...string) (int64, error) {
+	return c.client.Exists(ctx, keys...).Result()
+}
+
+// Expire sets the expiration time for a key.
+func (c *GoRedisClient) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	return c.client.Expire(ctx, key, expiration).Err()
+}
+
+// Publish sends payload to every subscriber of topic via Redis PUBLISH.
+func (c *GoRedisClient) Publish(ctx context.Context, topic string, payload string) error {
+	return c.client.Publish(ctx, topic, payload).Err()
+}
+
+// Subscribe subscribes to topic and calls handler with each message's
+// payload as it arrives. It blocks until ctx is canceled or the underlying
+// subscription fails, so callers run it in its own goroutine.
+func (c *GoRedisClient) Subscribe(ctx context.Context, topic string, handler func(payload string)) error {
+	sub := c.client.Subscribe(ctx, topic)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("redis: subscription to %s closed", topic)
+			}
+			handler(msg.Payload)
+		}
+	}
+}
+
+// takeTokenScript computes tokens = min(capacity, tokens + elapsed*refill) -
+// 1 against a per-key {tokens, last_ts} hash, so a read-modify-write never
+// races across concurrent replicas hitting the same bucket.
+const takeTokenScript = `
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "last_ts")
+local capacity = tonumber(ARGV[1])
+local refill = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local tokens = tonumber(bucket[1])
+local lastTS = tonumber(bucket[2])
+if tokens == nil then
+	tokens = capacity
+	lastTS = now
+end
+
+local elapsed = now - lastTS
+if elapsed > 0 then
+	tokens = math.min(capacity, tokens + elapsed * refill)
+end
+
+local allowed = 0
+if tokens >= 1 then
+	allowed = 1
+	tokens = tokens - 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tokens, "last_ts", now)
+redis.call("EXPIRE", KEYS[1], ttl)
+
+return {allowed, tostring(tokens)}
+`
+
+// TakeToken runs takeTokenScript via Redis EVAL, giving the distributed rate
+// limiter an atomic refill-then-take across every replica.
+func (c *GoRedisClient) TakeToken(ctx context.Context, key string, capacity, refill float64, now time.Time, ttl time.Duration) (bool, float64, error) {
+	ttlSeconds := int64(ttl.Seconds())
+	if ttlSeconds <= 0 {
+		ttlSeconds = 1
+	}
+
+	result, err := c.client.Eval(ctx, takeTokenScript, []string{key},
+		capacity, refill, now.Unix(), ttlSeconds).Result()
+	if err != nil {
+		return false, 0, fmt.Errorf("redis: take-token script failed for %s: %w", key, err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("redis: unexpected take-token result for %s: %v", key, result)
+	}
+
+	allowed, ok := values[0].(int64)
+	if !ok {
+		return false, 0, fmt.Errorf("redis: unexpected take-token allowed value for %s: %v", key, values[0])
+	}
+	remainingStr, ok := values[1].(string)
+	if !ok {
+		return false, 0, fmt.Errorf("redis: unexpected take-token remaining value for %s: %v", key, values[1])
+	}
+	remaining, err := strconv.ParseFloat(remainingStr, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("redis: failed to parse take-token remaining for %s: %w", key, err)
+	}
+
+	return allowed == 1, remaining, nil
+}
+
+// IncrBy atomically adds delta to the integer stored at key via Redis INCRBY.
+func (c *GoRedisClient) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.client.IncrBy(ctx, key, delta).Result()
+}
+
+// Close releases the underlying connection pool.
+func (c *GoRedisClient) Close() error {
+	return c.client.Close()
+}
+
+var _ RedisClient = (*GoRedisClient)(nil)
@@ -0,0 +1,44 @@
+package loadbalancer
+
+// SelectionStrategy picks which algorithm ServerPool.SelectBackend uses to
+// choose among a region's (or, absent one, the whole pool's) healthy
+// backends.
+type SelectionStrategy int
+
+const (
+	// Weighted is ServerPool's original behavior: GetNextPeerWeighted's
+	// weighted-round-robin index when no region is given, or the
+	// lowest-Score() candidate within the region otherwise. It's the zero
+	// value so a ServerPool that never calls SetSelectionStrategy keeps
+	// behaving exactly as it did before this type existed.
+	Weighted SelectionStrategy = iota
+	// RoundRobin ignores Score() and Weight, cycling through candidates in
+	// order.
+	RoundRobin
+	// LeastLoaded scans every candidate and picks the lowest Score() -
+	// correct, but O(n) per request and prone to herding traffic onto
+	// whichever backend briefly looks best.
+	LeastLoaded
+	// P2C (power-of-two-choices) samples two distinct candidates -
+	// weighted by Weight - and routes to whichever scores lower. Only two
+	// Score() calls per request (the weighted draw itself still sums every
+	// candidate's Weight) and, unlike LeastLoaded, doesn't herd: a backend that
+	// briefly looks best is never compared against more than one
+	// competitor in the same request.
+	P2C
+)
+
+// String returns the lowercase, underscore-separated name used in logs and
+// the "strategy" policy field (e.g. "least_loaded").
+func (s SelectionStrategy) String() string {
+	switch s {
+	case RoundRobin:
+		return "round_robin"
+	case LeastLoaded:
+		return "least_loaded"
+	case P2C:
+		return "p2c"
+	default:
+		return "weighted"
+	}
+}
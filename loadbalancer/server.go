@@ -24,4 +24,21 @@ func StartMockServer(port string) {
 	if err := server.ListenAndServe(); err != nil {
 		log.Fatalf("[ERROR] Mock backend server on port %s failed: %v", port, err)
 	}
+}
+
+// NewMockBackendServer builds (but does not start) a mock backend server on
+// port, identical to the one StartMockServer runs. Unlike StartMockServer it
+// hands back the *http.Server so a caller that needs to tear the instance
+// back down again (e.g. a BackendProvisioner undoing a scale-up) can call
+// Shutdown instead of leaking a goroutine.
+func NewMockBackendServer(port string) *http.Server {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		message := fmt.Sprintf("Response from backend server on port %s\n", port)
+		w.Write([]byte(message))
+	}
+
+	return &http.Server{
+		Addr:    ":" + port,
+		Handler: http.HandlerFunc(handler),
+	}
 }
\ No newline at end of file
@@ -0,0 +1,166 @@
+// Package server multiplexes HTTP, HTTPS, and gRPC traffic on a single TCP
+// listener via cmux, so an operator can serve the dashboard/lb/prometheus
+// endpoints and the gRPC control plane (health checks plus an admin API) off
+// one port, including TLS termination without a second listener.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Pool is the subset of ServerPool Multiplexer's graceful shutdown needs: a
+// total in-flight request count. It's its own small interface (rather than
+// importing package main's ServerPool type) so this package doesn't risk an
+// import cycle - main is what constructs a Multiplexer.
+type Pool interface {
+	TotalActiveConnections() int64
+}
+
+// Options configures a Multiplexer.
+type Options struct {
+	// Addr is the single TCP address the multiplexer listens on, e.g. ":8080".
+	Addr string
+	// HTTPHandler serves plain HTTP/1.x (and, if TLSConfig is set, HTTPS)
+	// connections - the dashboard, /lb, /prometheus, and friends.
+	HTTPHandler http.Handler
+	// GRPCServer serves HTTP/2-preface connections. Callers register
+	// whatever services they want exposed (grpc_health_v1.Health is always
+	// added by New) before passing it in.
+	GRPCServer *grpc.Server
+	// TLSConfig, if non-nil, is used to terminate TLS ClientHello
+	// connections ahead of HTTPHandler - typically built by
+	// loadbalancer.NewMTLSServerFromEnv(). Nil skips HTTPS, leaving plain
+	// HTTP and gRPC.
+	TLSConfig *tls.Config
+	// Pool backs graceful shutdown's drain wait; nil skips draining and
+	// proceeds straight to Shutdown.
+	Pool Pool
+	// DrainTimeout bounds how long Shutdown waits for Pool's in-flight
+	// connections to reach zero before giving up anyway. Defaults to 30s.
+	DrainTimeout time.Duration
+	// DrainPollInterval is how often Shutdown polls Pool while draining.
+	// Defaults to 250ms.
+	DrainPollInterval time.Duration
+}
+
+// Multiplexer owns one TCP listener split by cmux into HTTP(S) and gRPC
+// sub-listeners, each served by its own goroutine.
+type Multiplexer struct {
+	opts       Options
+	root       cmux.CMux
+	httpServer *http.Server
+	tlsServer  *http.Server
+	grpcServer *grpc.Server
+	healthSrv  *health.Server
+}
+
+// New opens opts.Addr and starts serving it: connections are matched, in
+// priority order, as an HTTP/2 preface carrying a gRPC content-type (routed
+// to GRPCServer), a TLS ClientHello (routed to HTTPHandler over HTTPS, if
+// TLSConfig is set), or an HTTP/1.x request (routed to HTTPHandler over
+// plain HTTP/1.x). New returns once every sub-listener is accepting; serving
+// itself runs in background goroutines.
+func New(opts Options) (*Multiplexer, error) {
+	if opts.DrainTimeout == 0 {
+		opts.DrainTimeout = 30 * time.Second
+	}
+	if opts.DrainPollInterval == 0 {
+		opts.DrainPollInterval = 250 * time.Millisecond
+	}
+
+	lis, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("server: failed to listen on %s: %w", opts.Addr, err)
+	}
+	root := cmux.New(lis)
+
+	healthSrv := health.NewServer()
+	if opts.GRPCServer != nil {
+		healthpb.RegisterHealthServer(opts.GRPCServer, healthSrv)
+		healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	}
+
+	m := &Multiplexer{opts: opts, root: root, grpcServer: opts.GRPCServer, healthSrv: healthSrv}
+
+	grpcListener := root.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
+
+	var tlsListener net.Listener
+	if opts.TLSConfig != nil {
+		tlsListener = root.Match(cmux.TLS())
+		m.tlsServer = &http.Server{Handler: opts.HTTPHandler, TLSConfig: opts.TLSConfig}
+	}
+	httpListener := root.Match(cmux.HTTP1Fast())
+	m.httpServer = &http.Server{Handler: opts.HTTPHandler}
+
+	if opts.GRPCServer != nil {
+		go func() {
+			if err := opts.GRPCServer.Serve(grpcListener); err != nil {
+				log.Printf("[ERROR] server: gRPC listener stopped: %v", err)
+			}
+		}()
+	}
+	if tlsListener != nil {
+		go func() {
+			if err := m.tlsServer.Serve(tls.NewListener(tlsListener, opts.TLSConfig)); err != nil && err != http.ErrServerClosed {
+				log.Printf("[ERROR] server: HTTPS listener stopped: %v", err)
+			}
+		}()
+	}
+	go func() {
+		if err := m.httpServer.Serve(httpListener); err != nil && err != http.ErrServerClosed {
+			log.Printf("[ERROR] server: HTTP listener stopped: %v", err)
+		}
+	}()
+	go func() {
+		if err := root.Serve(); err != nil {
+			log.Printf("[INFO] server: cmux root listener stopped: %v", err)
+		}
+	}()
+
+	return m, nil
+}
+
+// Shutdown stops accepting new connections on the cmux root listener, waits
+// (up to opts.DrainTimeout, or until ctx is canceled) for opts.Pool's
+// in-flight connections to reach zero, then gracefully stops the gRPC server
+// and the HTTP/HTTPS servers.
+func (m *Multiplexer) Shutdown(ctx context.Context) error {
+	m.healthSrv.Shutdown()
+	m.root.Close()
+
+	if m.opts.Pool != nil {
+		deadline := time.Now().Add(m.opts.DrainTimeout)
+		ticker := time.NewTicker(m.opts.DrainPollInterval)
+		defer ticker.Stop()
+	drainLoop:
+		for time.Now().Before(deadline) {
+			if m.opts.Pool.TotalActiveConnections() == 0 {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				break drainLoop
+			case <-ticker.C:
+			}
+		}
+	}
+
+	if m.grpcServer != nil {
+		m.grpcServer.GracefulStop()
+	}
+	if m.tlsServer != nil {
+		_ = m.tlsServer.Shutdown(ctx)
+	}
+	return m.httpServer.Shutdown(ctx)
+}
@@ -0,0 +1,116 @@
+package loadbalancer
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+// SessionRebalancerPool is the subset of ServerPool DrainController needs:
+// each backend's current sticky-session count, which backends are healthy,
+// and a way to evict sessions from an overloaded one. Keeping this to
+// primitive/string types (never *main.Backend) avoids an import cycle back
+// into package main, the same reasoning ServerPoolInterface documents for
+// AutoScaler.
+type SessionRebalancerPool interface {
+	// HealthyBackendURLs returns the URLs of every currently alive backend.
+	HealthyBackendURLs() []string
+	// SessionCountsByBackend returns how many sticky sessions each backend
+	// currently holds, keyed by backend URL.
+	SessionCountsByBackend() map[string]int64
+	// EvictSession picks up to count sticky sessions assigned to backendURL,
+	// marks them draining, and returns their session IDs.
+	EvictSession(backendURL string, count int) []string
+}
+
+// RebalanceOptions configures a DrainController.
+type RebalanceOptions struct {
+	// Tolerance is how far over the per-backend target (totalSessions /
+	// healthyBackends) a backend may run before DrainController starts
+	// evicting its sessions, expressed as a fraction (e.g. 0.1 for 10%).
+	Tolerance float64
+	// DrainRate is the baseline eviction rate in sessions/sec; the actual
+	// per-tick budget scales up with the per-backend target so a lightly
+	// loaded cluster doesn't thrash evicting its few sessions every tick.
+	DrainRate float64
+	// Interval is how often DrainController re-checks backend session
+	// distribution.
+	Interval time.Duration
+}
+
+// DrainController periodically compares each backend's sticky-session count
+// against a fair-share target and gradually evicts sessions from backends
+// running over it, so a client lands back on SelectBackend's current pick
+// (e.g. after a scale-up, or once an unhealthy peer returns and should get
+// its fair share of sessions back) instead of piling onto a backend that's
+// no longer the right choice.
+type DrainController struct {
+	pool SessionRebalancerPool
+	opts RebalanceOptions
+}
+
+// NewDrainController returns a DrainController rebalancing pool per opts.
+func NewDrainController(pool SessionRebalancerPool, opts RebalanceOptions) *DrainController {
+	return &DrainController{pool: pool, opts: opts}
+}
+
+// Start runs the rebalancing loop until ctx is canceled. Callers run it in
+// its own goroutine.
+func (dc *DrainController) Start(ctx context.Context) {
+	ticker := time.NewTicker(dc.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			dc.rebalanceOnce()
+		}
+	}
+}
+
+// rebalanceOnce evicts sessions from any backend running more than
+// Tolerance over the fair-share target, up to the tick's drain budget.
+func (dc *DrainController) rebalanceOnce() {
+	healthy := dc.pool.HealthyBackendURLs()
+	if len(healthy) == 0 {
+		return
+	}
+
+	counts := dc.pool.SessionCountsByBackend()
+	var total int64
+	for _, url := range healthy {
+		total += counts[url]
+	}
+	if total == 0 {
+		return
+	}
+
+	target := float64(total) / float64(len(healthy))
+	limit := target * (1 + dc.opts.Tolerance)
+
+	// The per-tick eviction budget scales with target (the average load per
+	// backend), so a heavily loaded cluster rebalances faster than a lightly
+	// loaded one where evicting even one session is a large swing.
+	budget := dc.opts.DrainRate * dc.opts.Interval.Seconds() * target
+
+	for _, url := range healthy {
+		count := counts[url]
+		if float64(count) <= limit {
+			continue
+		}
+
+		overflow := float64(count) - target
+		evictCount := int(math.Min(overflow, budget))
+		if evictCount < 1 {
+			continue
+		}
+
+		evicted := dc.pool.EvictSession(url, evictCount)
+		if len(evicted) > 0 {
+			log.Printf("[INFO] DrainController: evicted %d/%d sticky sessions from %s (target=%.1f, tolerance=%.0f%%)",
+				len(evicted), count, url, target, dc.opts.Tolerance*100)
+		}
+	}
+}
@@ -0,0 +1,491 @@
+package loadbalancer
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// SessionStore is the storage backend StickySessionManager and
+// AutoScalingStateManager read and write through. RedisClient (and therefore
+// GoRedisClient and MockRedisClient) already satisfies this shape, so the
+// Redis-backed implementations double as SessionStores; MemoryLRUStore and
+// LevelDBStore round it out for single-node or embedded deploys that don't
+// want a Redis dependency.
+type SessionStore = RedisClient
+
+// StoreBackend selects which SessionStore implementation NewSessionStore builds.
+type StoreBackend string
+
+const (
+	StoreBackendRedis   StoreBackend = "redis"
+	StoreBackendMemory  StoreBackend = "memory"
+	StoreBackendLevelDB StoreBackend = "leveldb"
+)
+
+// NewSessionStore builds the configured SessionStore backend. For
+// StoreBackendRedis, redisCfg must be non-nil; for StoreBackendLevelDB,
+// levelDBPath selects the on-disk database directory.
+func NewSessionStore(backend StoreBackend, redisCfg *RedisConfig, levelDBPath string, memoryCapacity int) (SessionStore, error) {
+	switch backend {
+	case StoreBackendRedis:
+		if redisCfg == nil {
+			return nil, fmt.Errorf("sessionstore: redis config required for redis backend")
+		}
+		return NewGoRedisClient(*redisCfg)
+	case StoreBackendLevelDB:
+		return NewLevelDBStore(levelDBPath)
+	case StoreBackendMemory, "":
+		return NewMemoryLRUStore(memoryCapacity), nil
+	default:
+		return nil, fmt.Errorf("sessionstore: unknown backend %q", backend)
+	}
+}
+
+// MemoryLRUStore is an in-process SessionStore backed by a bounded LRU cache.
+// It's intended for single-node deploys that want sticky sessions without a
+// Redis dependency; state does not survive a restart and isn't shared across
+// replicas.
+type MemoryLRUStore struct {
+	localPubSub
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time // zero means no expiration
+}
+
+// NewMemoryLRUStore creates a MemoryLRUStore holding at most capacity entries.
+// A non-positive capacity defaults to 10000.
+func NewMemoryLRUStore(capacity int) *MemoryLRUStore {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &MemoryLRUStore{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Set stores a key-value pair with expiration, evicting the least recently
+// used entry if the store is at capacity.
+func (m *MemoryLRUStore) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	str := fmt.Sprintf("%v", value)
+	if b, ok := value.([]byte); ok {
+		str = string(b)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expiresAt time.Time
+	if expiration > 0 {
+		expiresAt = time.Now().Add(expiration)
+	}
+
+	m.setLocked(key, str, expiresAt)
+	return nil
+}
+
+// SetNX stores a key-value pair with expiration only if key is absent or
+// expired, mirroring Redis SET NX PX.
+func (m *MemoryLRUStore) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	if el, ok := m.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			m.mu.Unlock()
+			return false, nil
+		}
+	}
+	m.mu.Unlock()
+
+	if err := m.Set(ctx, key, value, expiration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Get retrieves a value by key, returning "" if the key is missing or expired.
+func (m *MemoryLRUStore) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return "", nil
+	}
+	entry := el.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		m.order.Remove(el)
+		delete(m.items, key)
+		return "", nil
+	}
+	m.order.MoveToFront(el)
+	return entry.value, nil
+}
+
+// Del deletes keys.
+func (m *MemoryLRUStore) Del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := m.items[key]; ok {
+			m.order.Remove(el)
+			delete(m.items, key)
+		}
+	}
+	return nil
+}
+
+// Exists checks how many of the given keys exist and are unexpired.
+func (m *MemoryLRUStore) Exists(ctx context.Context, keys ...string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count int64
+	now := time.Now()
+	for _, key := range keys {
+		if el, ok := m.items[key]; ok {
+			entry := el.Value.(*lruEntry)
+			if entry.expiresAt.IsZero() || now.Before(entry.expiresAt) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// Expire updates the expiration time for a key.
+func (m *MemoryLRUStore) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		el.Value.(*lruEntry).expiresAt = time.Now().Add(expiration)
+	}
+	return nil
+}
+
+// IncrBy atomically adds delta to the integer stored at key (creating it as
+// delta if absent), mirroring Redis INCRBY. An expired key is treated as
+// absent, same as Get.
+func (m *MemoryLRUStore) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current int64
+	var expiresAt time.Time
+	if el, ok := m.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		if entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt) {
+			parsed, err := strconv.ParseInt(entry.value, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("memory store: value for %s is not an integer: %w", key, err)
+			}
+			current = parsed
+			expiresAt = entry.expiresAt
+		}
+	}
+
+	current += delta
+	m.setLocked(key, strconv.FormatInt(current, 10), expiresAt)
+	return current, nil
+}
+
+// TakeToken implements the same tokens = min(capacity, tokens +
+// elapsed*refill) - 1 logic as GoRedisClient's Lua script and
+// MockRedisClient.TakeToken, guarded by m.mu instead of Redis's atomicity -
+// MemoryLRUStore has no concurrent replicas to race against.
+func (m *MemoryLRUStore) TakeToken(ctx context.Context, key string, capacity, refill float64, now time.Time, ttl time.Duration) (bool, float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var bucket mockTokenBucket
+	if el, ok := m.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		if entry.expiresAt.IsZero() || now.Before(entry.expiresAt) {
+			if err := json.Unmarshal([]byte(entry.value), &bucket); err != nil {
+				return false, 0, fmt.Errorf("memory store: corrupt token bucket for %s: %w", key, err)
+			}
+		} else {
+			bucket = mockTokenBucket{Tokens: capacity, LastTS: now}
+		}
+	} else {
+		bucket = mockTokenBucket{Tokens: capacity, LastTS: now}
+	}
+
+	elapsed := now.Sub(bucket.LastTS).Seconds()
+	if elapsed > 0 {
+		bucket.Tokens = math.Min(capacity, bucket.Tokens+elapsed*refill)
+	}
+	bucket.LastTS = now
+
+	allowed := bucket.Tokens >= 1
+	if allowed {
+		bucket.Tokens--
+	}
+
+	data, err := json.Marshal(bucket)
+	if err != nil {
+		return false, 0, fmt.Errorf("memory store: failed to marshal token bucket for %s: %w", key, err)
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+	m.setLocked(key, string(data), expiresAt)
+
+	return allowed, bucket.Tokens, nil
+}
+
+// setLocked stores value under key with absolute expiry expiresAt (zero
+// means no expiration), evicting the least recently used entry if the store
+// is at capacity. Callers must hold m.mu.
+func (m *MemoryLRUStore) setLocked(key, value string, expiresAt time.Time) {
+	if el, ok := m.items[key]; ok {
+		el.Value.(*lruEntry).value = value
+		el.Value.(*lruEntry).expiresAt = expiresAt
+		m.order.MoveToFront(el)
+		return
+	}
+
+	el := m.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	m.items[key] = el
+
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		if oldest == nil {
+			break
+		}
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+var _ SessionStore = (*MemoryLRUStore)(nil)
+
+// LevelDBStore is a SessionStore backed by an embedded LevelDB database,
+// for single-node deploys that want sticky sessions to survive a restart
+// without standing up Redis.
+type LevelDBStore struct {
+	localPubSub
+	mu sync.Mutex
+	db *leveldb.DB
+}
+
+// NewLevelDBStore opens (creating if necessary) the LevelDB database at path.
+func NewLevelDBStore(path string) (*LevelDBStore, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open leveldb store at %s: %w", path, err)
+	}
+	return &LevelDBStore{db: db}, nil
+}
+
+// Set stores a key-value pair. LevelDB has no native TTL support, so
+// expiration is encoded alongside the value and enforced on Get.
+func (l *LevelDBStore) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	str := fmt.Sprintf("%v", value)
+	if b, ok := value.([]byte); ok {
+		str = string(b)
+	}
+	var expiresAt time.Time
+	if expiration > 0 {
+		expiresAt = time.Now().Add(expiration)
+	}
+	return l.putRaw(key, str, expiresAt)
+}
+
+// Get retrieves a value by key, returning "" if missing or expired.
+func (l *LevelDBStore) Get(ctx context.Context, key string) (string, error) {
+	value, _, found, err := l.getRaw(key)
+	if err != nil || !found {
+		return "", err
+	}
+	return value, nil
+}
+
+// getRaw returns the value and absolute expiry (zero means no expiration)
+// stored for key, and whether it was found and unexpired. An expired entry
+// is deleted and reported not found, same as Get.
+func (l *LevelDBStore) getRaw(key string) (value string, expiresAt time.Time, found bool, err error) {
+	raw, err := l.db.Get([]byte(key), nil)
+	if err == leveldb.ErrNotFound {
+		return "", time.Time{}, false, nil
+	}
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return "", time.Time{}, false, fmt.Errorf("failed to decode leveldb entry for %s: malformed value", key)
+	}
+	expiresAtNano, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false, fmt.Errorf("failed to decode leveldb entry for %s: %w", key, err)
+	}
+	if expiresAtNano != 0 {
+		expiresAt = time.Unix(0, expiresAtNano)
+		if time.Now().After(expiresAt) {
+			_ = l.db.Delete([]byte(key), nil)
+			return "", time.Time{}, false, nil
+		}
+	}
+	return parts[1], expiresAt, true, nil
+}
+
+// putRaw writes value under key with absolute expiry expiresAt (zero means
+// no expiration), the on-disk format Get/getRaw parse.
+func (l *LevelDBStore) putRaw(key, value string, expiresAt time.Time) error {
+	var expiresAtNano int64
+	if !expiresAt.IsZero() {
+		expiresAtNano = expiresAt.UnixNano()
+	}
+	return l.db.Put([]byte(key), []byte(fmt.Sprintf("%d|%s", expiresAtNano, value)), nil)
+}
+
+// SetNX stores a key-value pair with expiration only if key is absent or
+// expired, mirroring Redis SET NX PX.
+func (l *LevelDBStore) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	existing, err := l.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if existing != "" {
+		return false, nil
+	}
+	if err := l.Set(ctx, key, value, expiration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Del deletes keys.
+func (l *LevelDBStore) Del(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := l.db.Delete([]byte(key), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exists checks how many of the given keys exist.
+func (l *LevelDBStore) Exists(ctx context.Context, keys ...string) (int64, error) {
+	var count int64
+	for _, key := range keys {
+		if ok, err := l.db.Has([]byte(key), nil); err != nil {
+			return 0, err
+		} else if ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Expire re-stamps key's expiration by rewriting its current value with the
+// new expiry. A missing or expired key is a no-op.
+func (l *LevelDBStore) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	value, err := l.Get(ctx, key)
+	if err != nil || value == "" {
+		return err
+	}
+	return l.Set(ctx, key, value, expiration)
+}
+
+// IncrBy atomically adds delta to the integer stored at key (creating it as
+// delta if absent), mirroring Redis INCRBY. l.mu guards the read-modify-write
+// against concurrent callers in this process; preserves key's existing
+// expiry, same as Redis INCRBY.
+func (l *LevelDBStore) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var current int64
+	existing, expiresAt, found, err := l.getRaw(key)
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		parsed, err := strconv.ParseInt(existing, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("leveldb store: value for %s is not an integer: %w", key, err)
+		}
+		current = parsed
+	}
+
+	current += delta
+	if err := l.putRaw(key, strconv.FormatInt(current, 10), expiresAt); err != nil {
+		return 0, err
+	}
+	return current, nil
+}
+
+// TakeToken implements the same tokens = min(capacity, tokens +
+// elapsed*refill) - 1 logic as GoRedisClient's Lua script and
+// MockRedisClient.TakeToken, guarded by l.mu instead of Redis's atomicity -
+// LevelDBStore has no concurrent replicas to race against.
+func (l *LevelDBStore) TakeToken(ctx context.Context, key string, capacity, refill float64, now time.Time, ttl time.Duration) (bool, float64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var bucket mockTokenBucket
+	existing, _, found, err := l.getRaw(key)
+	if err != nil {
+		return false, 0, err
+	}
+	if found {
+		if err := json.Unmarshal([]byte(existing), &bucket); err != nil {
+			return false, 0, fmt.Errorf("leveldb store: corrupt token bucket for %s: %w", key, err)
+		}
+	} else {
+		bucket = mockTokenBucket{Tokens: capacity, LastTS: now}
+	}
+
+	elapsed := now.Sub(bucket.LastTS).Seconds()
+	if elapsed > 0 {
+		bucket.Tokens = math.Min(capacity, bucket.Tokens+elapsed*refill)
+	}
+	bucket.LastTS = now
+
+	allowed := bucket.Tokens >= 1
+	if allowed {
+		bucket.Tokens--
+	}
+
+	data, err := json.Marshal(bucket)
+	if err != nil {
+		return false, 0, fmt.Errorf("leveldb store: failed to marshal token bucket for %s: %w", key, err)
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+	if err := l.putRaw(key, string(data), expiresAt); err != nil {
+		return false, 0, err
+	}
+
+	return allowed, bucket.Tokens, nil
+}
+
+// Close releases the underlying database handle.
+func (l *LevelDBStore) Close() error {
+	return l.db.Close()
+}
+
+var _ SessionStore = (*LevelDBStore)(nil)
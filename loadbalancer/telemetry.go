@@ -0,0 +1,29 @@
+package loadbalancer
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TelemetryProvider is the common interface for observability backends used by
+// the proxy and circuit-breaker call sites. SimpleTelemetryProvider and
+// OTelTelemetryProvider both satisfy it so operators can switch providers
+// without touching request-handling code.
+type TelemetryProvider interface {
+	LogInfo(message string, fields ...interface{})
+	LogError(message string, err error, fields ...interface{})
+	LogWarn(message string, fields ...interface{})
+	LogDebug(message string, fields ...interface{})
+	TraceRequest(r *http.Request) (context.Context, interface{})
+	// EndSpan ends the span TraceRequest started. Callers defer it right
+	// after TraceRequest so the span covers the whole request.
+	EndSpan(span interface{})
+	RecordRequestMetrics(ctx context.Context, backend, method, status string, duration time.Duration)
+	RecordCircuitBreakerStateChange(ctx context.Context, backend, fromState, toState string)
+	RecordBackendConnection(ctx context.Context, backend string, delta int64)
+	Shutdown(ctx context.Context) error
+	GetLogger() interface{}
+}
+
+var _ TelemetryProvider = (*SimpleTelemetryProvider)(nil)
@@ -0,0 +1,294 @@
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// otelInstruments holds the metric instruments recorded by OTelTelemetryProvider.
+type otelInstruments struct {
+	requestDuration    metric.Float64Histogram
+	backendConnections metric.Int64UpDownCounter
+	breakerTransitions metric.Int64Counter
+}
+
+// OTelTelemetryProvider implements TelemetryProvider on top of the OpenTelemetry
+// SDK: structured logs go through the otel logs bridge, traces propagate W3C
+// traceparent to backends via the reverse proxy, and metrics are recorded as
+// histograms/counters exported over OTLP.
+type OTelTelemetryProvider struct {
+	serviceName    string
+	logger         *slog.Logger
+	loggerProvider *sdklog.LoggerProvider
+	tracerProvider *sdktrace.TracerProvider
+	tracer         trace.Tracer
+	meterProvider  *sdkmetric.MeterProvider
+	propagator     propagation.TextMapPropagator
+	instruments    otelInstruments
+}
+
+// NewOTelTelemetryProvider builds an OTelTelemetryProvider configured from env vars:
+//
+//	OTEL_EXPORTER_OTLP_PROTOCOL   grpc|http/protobuf (default grpc)
+//	OTEL_EXPORTER_OTLP_ENDPOINT   collector endpoint, e.g. localhost:4317
+//	OTEL_SERVICE_NAME             overrides serviceName when set
+//
+// Shutdown must be called to flush the trace, metric, and log providers.
+func NewOTelTelemetryProvider(ctx context.Context, serviceName string) (*OTelTelemetryProvider, error) {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		serviceName = name
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build otel resource: %w", err)
+	}
+
+	useHTTP := strings.EqualFold(os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"), "http/protobuf")
+
+	traceExporter, err := newTraceExporter(ctx, useHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build otlp trace exporter: %w", err)
+	}
+	metricExporter, err := newMetricExporter(ctx, useHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build otlp metric exporter: %w", err)
+	}
+	logExporter, err := newLogExporter(ctx, useHTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build otlp log exporter: %w", err)
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(meterProvider)
+
+	loggerProvider := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
+		sdklog.WithResource(res),
+	)
+
+	propagator := propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+	otel.SetTextMapPropagator(propagator)
+
+	meter := meterProvider.Meter(serviceName)
+	requestDuration, err := meter.Float64Histogram(
+		"loadbalancer.request.duration",
+		metric.WithDescription("Request duration by backend, method, and status"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request duration histogram: %w", err)
+	}
+	backendConnections, err := meter.Int64UpDownCounter(
+		"loadbalancer.backend.connections",
+		metric.WithDescription("In-flight connections per backend"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backend connections counter: %w", err)
+	}
+	breakerTransitions, err := meter.Int64Counter(
+		"loadbalancer.circuit_breaker.transitions",
+		metric.WithDescription("Circuit breaker state transitions per backend"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create circuit breaker transitions counter: %w", err)
+	}
+
+	return &OTelTelemetryProvider{
+		serviceName:    serviceName,
+		logger:         slog.New(otelSlogHandler{provider: loggerProvider, scope: serviceName}),
+		loggerProvider: loggerProvider,
+		tracerProvider: tracerProvider,
+		tracer:         tracerProvider.Tracer(serviceName),
+		meterProvider:  meterProvider,
+		propagator:     propagator,
+		instruments: otelInstruments{
+			requestDuration:    requestDuration,
+			backendConnections: backendConnections,
+			breakerTransitions: breakerTransitions,
+		},
+	}, nil
+}
+
+// otelSlogHandler bridges slog records into the OTel logs SDK.
+type otelSlogHandler struct {
+	provider *sdklog.LoggerProvider
+	scope    string
+}
+
+func (h otelSlogHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h otelSlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	logger := h.provider.Logger(h.scope)
+	var rec log.Record
+	rec.SetTimestamp(r.Time)
+	rec.SetBody(log.StringValue(r.Message))
+	rec.SetSeverityText(r.Level.String())
+	r.Attrs(func(a slog.Attr) bool {
+		rec.AddAttributes(log.String(a.Key, fmt.Sprint(a.Value.Any())))
+		return true
+	})
+	logger.Emit(ctx, rec)
+	return nil
+}
+
+func (h otelSlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return h }
+func (h otelSlogHandler) WithGroup(name string) slog.Handler       { return h }
+
+func newTraceExporter(ctx context.Context, useHTTP bool) (sdktrace.SpanExporter, error) {
+	if useHTTP {
+		return otlptracehttp.New(ctx)
+	}
+	return otlptracegrpc.New(ctx)
+}
+
+func newMetricExporter(ctx context.Context, useHTTP bool) (sdkmetric.Exporter, error) {
+	if useHTTP {
+		return otlpmetrichttp.New(ctx)
+	}
+	return otlpmetricgrpc.New(ctx)
+}
+
+func newLogExporter(ctx context.Context, useHTTP bool) (sdklog.Exporter, error) {
+	if useHTTP {
+		return otlploghttp.New(ctx)
+	}
+	return otlploggrpc.New(ctx)
+}
+
+// LogInfo logs an informational structured message.
+func (tp *OTelTelemetryProvider) LogInfo(message string, fields ...interface{}) {
+	tp.logger.Info(message, fields...)
+}
+
+// LogError logs an error with structured fields.
+func (tp *OTelTelemetryProvider) LogError(message string, err error, fields ...interface{}) {
+	tp.logger.Error(message, append(fields, "error", err)...)
+}
+
+// LogWarn logs a warning structured message.
+func (tp *OTelTelemetryProvider) LogWarn(message string, fields ...interface{}) {
+	tp.logger.Warn(message, fields...)
+}
+
+// LogDebug logs a debug structured message.
+func (tp *OTelTelemetryProvider) LogDebug(message string, fields ...interface{}) {
+	tp.logger.Debug(message, fields...)
+}
+
+// TraceRequest starts a span for the incoming request, extracting any W3C
+// traceparent/baggage headers so the span joins an upstream trace, and
+// re-injects the propagated headers into the request so the reverse proxy
+// carries the trace context to the backend.
+func (tp *OTelTelemetryProvider) TraceRequest(r *http.Request) (context.Context, interface{}) {
+	ctx := tp.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tp.tracer.Start(ctx, fmt.Sprintf("%s %s", r.Method, r.URL.Path),
+		trace.WithAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.target", r.URL.Path),
+		),
+	)
+	tp.propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+	return ctx, span
+}
+
+// EndSpan ends the span TraceRequest started, exporting it via the
+// configured trace exporter.
+func (tp *OTelTelemetryProvider) EndSpan(span interface{}) {
+	s, ok := span.(trace.Span)
+	if !ok {
+		return
+	}
+	s.End()
+}
+
+// RecordRequestMetrics records a histogram sample for request duration,
+// labeled by backend, method, and status.
+func (tp *OTelTelemetryProvider) RecordRequestMetrics(ctx context.Context, backend, method, status string, duration time.Duration) {
+	tp.instruments.requestDuration.Record(ctx, duration.Seconds(),
+		metric.WithAttributes(
+			attribute.String("backend", backend),
+			attribute.String("method", method),
+			attribute.String("status", status),
+		),
+	)
+}
+
+// RecordCircuitBreakerStateChange increments the circuit-breaker transition
+// counter for the given backend.
+func (tp *OTelTelemetryProvider) RecordCircuitBreakerStateChange(ctx context.Context, backend, fromState, toState string) {
+	tp.instruments.breakerTransitions.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("backend", backend),
+			attribute.String("from_state", fromState),
+			attribute.String("to_state", toState),
+		),
+	)
+	tp.LogInfo("Circuit breaker state changed", "backend", backend, "from_state", fromState, "to_state", toState)
+}
+
+// RecordBackendConnection adjusts the up-down counter tracking in-flight
+// connections per backend.
+func (tp *OTelTelemetryProvider) RecordBackendConnection(ctx context.Context, backend string, delta int64) {
+	tp.instruments.backendConnections.Add(ctx, delta, metric.WithAttributes(attribute.String("backend", backend)))
+}
+
+// Shutdown flushes and closes the trace, metric, and log providers.
+func (tp *OTelTelemetryProvider) Shutdown(ctx context.Context) error {
+	if err := tp.tracerProvider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("tracer provider shutdown: %w", err)
+	}
+	if err := tp.meterProvider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("meter provider shutdown: %w", err)
+	}
+	if err := tp.loggerProvider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("logger provider shutdown: %w", err)
+	}
+	return nil
+}
+
+// GetLogger returns the underlying slog.Logger used for structured logging.
+func (tp *OTelTelemetryProvider) GetLogger() interface{} {
+	return tp.logger
+}
+
+var _ TelemetryProvider = (*OTelTelemetryProvider)(nil)
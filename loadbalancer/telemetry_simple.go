@@ -39,12 +39,25 @@ func (tp *SimpleTelemetryProvider) LogDebug(message string, fields ...interface{
 	log.Printf("[DEBUG] %s: %s", tp.serviceName, message)
 }
 
+// simpleSpan is the span value SimpleTelemetryProvider.TraceRequest returns;
+// EndSpan logs how long it was open since there's no real tracing backend.
+type simpleSpan struct {
+	startTime time.Time
+}
+
 // TraceRequest creates a trace context (simplified implementation)
 func (tp *SimpleTelemetryProvider) TraceRequest(r *http.Request) (context.Context, interface{}) {
-	span := struct {
-		StartTime time.Time
-	}{StartTime: time.Now()}
-	return r.Context(), span
+	return r.Context(), simpleSpan{startTime: time.Now()}
+}
+
+// EndSpan logs the span's duration; SimpleTelemetryProvider has no real
+// tracing backend to export a span to.
+func (tp *SimpleTelemetryProvider) EndSpan(span interface{}) {
+	s, ok := span.(simpleSpan)
+	if !ok {
+		return
+	}
+	log.Printf("[DEBUG] %s: Request span ended after %s", tp.serviceName, time.Since(s.startTime))
 }
 
 // RecordRequestMetrics records request metrics (placeholder)
@@ -2,10 +2,12 @@ package loadbalancer
 
 import (
     "fmt"
+    "hash/fnv"
     "log"
     "net"
     "net/http"
     "regexp"
+    "strconv"
     "strings"
     "sync"
     "time"
@@ -20,6 +22,13 @@ type TrafficPolicy struct {
 	Priority    int                   `json:"priority"`
 	Weight      int                   `json:"weight"` // for canary deployments
 	Conditions  PolicyConditions      `json:"conditions"`
+	Namespace   string                 `json:"namespace"` // Consul Enterprise namespace this policy is scoped to ("" = all namespaces)
+	Partition   string                 `json:"partition"` // Consul 1.11+ admin partition this policy is scoped to ("" or "default" = all partitions)
+	// Strategy overrides the ServerPool's construction-time selection
+	// strategy for requests this policy's "allow" action applies to
+	// (ignored otherwise). Zero value (Weighted) leaves the pool's default
+	// in effect.
+	Strategy  SelectionStrategy `json:"strategy"`
 	CreatedAt   time.Time             `json:"created_at"`
 	UpdatedAt   time.Time             `json:"updated_at"`
 }
@@ -36,21 +45,38 @@ const (
 
 // PolicyRule defines a single matching rule
 type PolicyRule struct {
-	Field       string      `json:"field"`       // header, query, path, geo, etc.
+	Field       string      `json:"field"`       // header, query, path, region, country, continent, city, subdivision, asn
 	Operator    string      `json:"operator"`    // equals, contains, regex, etc.
 	Value       string      `json:"value"`      // the value to match against
 	Action      string      `json:"action"`     // allow, deny, redirect
 	Backend     string      `json:"backend"`    // specific backend when action is redirect
 	Weight      int         `json:"weight"`     // weight for load balancing
+	Namespace   string      `json:"namespace"`  // Consul Enterprise namespace this rule's backend must be in scope for ("" = any)
+	Partition   string      `json:"partition"`  // Consul admin partition this rule's backend must be in scope for ("" or "default" = any)
 }
 
 // PolicyConditions define when a policy applies
 type PolicyConditions struct {
     TimeRange         string   `json:"time_range"`
-    PercentageTraffic int      `json:"percentageTraffic"`
+    PercentageTraffic float64  `json:"percentageTraffic"` // supports fractional percentages, e.g. 2.5
     RequestRate       int64    `json:"request_rate"`
     MinVersion        string   `json:"min_version"`
     Maintainers       []string `json:"maintainers"`
+
+    // CohortKey selects what a canary policy hashes to assign a request to a
+    // cohort: "header:<Name>", "cookie:<Name>", or "ip" (the default). Using
+    // a stable per-user key instead of path|UA|IP keeps a user's cohort
+    // assignment stable across unrelated request changes.
+    CohortKey string `json:"cohort_key"`
+    // Salt decorrelates cohort assignment between independent canaries that
+    // share the same CohortKey, so rolling out two unrelated experiments
+    // doesn't put the same users in both canaries every time.
+    Salt string `json:"salt"`
+    // RendezvousHash selects among the canary backends listed in Rules using
+    // rendezvous (highest random weight) hashing instead of always picking
+    // the first in-scope rule, so adding/removing a canary backend only
+    // remaps ~1/N of traffic.
+    RendezvousHash bool `json:"rendezvous_hash"`
 }
 
 // TrafficPolicyEngine manages traffic routing policies
@@ -58,26 +84,83 @@ type TrafficPolicyEngine struct {
     policies   []TrafficPolicy
     backendMap map[string]interface{} // URL to backend mapping
     mutex      sync.RWMutex
+
+    // ossMode mirrors Consul's own enterprise/OSS split: when true (the
+    // default), admin partitions don't exist, so AddPolicy rejects any
+    // policy scoped to a non-default partition.
+    ossMode bool
+
+    // geoProvider resolves country/continent/ASN/city/subdivision for
+    // PolicyTypeGeo rules beyond the legacy "region" field. Nil means those
+    // fields never match.
+    geoProvider GeoIPProvider
+
+    // logger records deny and redirect decisions with structured fields
+    // (allow decisions are not logged). Defaults to a no-op logger.
+    logger Logger
+}
+
+// TrafficPolicyEngineOption configures optional TrafficPolicyEngine behavior.
+type TrafficPolicyEngineOption func(*TrafficPolicyEngine)
+
+// WithOSSMode toggles Consul OSS compatibility mode. Enabled by default,
+// matching a plain/single-partition Consul deployment; pass
+// WithOSSMode(false) when admin partitions (Consul Enterprise 1.11+) are
+// actually available so partition-scoped policies can be added.
+func WithOSSMode(enabled bool) TrafficPolicyEngineOption {
+	return func(tpe *TrafficPolicyEngine) {
+		tpe.ossMode = enabled
+	}
+}
+
+// WithGeoIPProvider enables the country/continent/asn/city/subdivision
+// PolicyRule fields by giving the engine a GeoIPProvider (typically a
+// MaxMindGeoIPProvider) to resolve them against.
+func WithGeoIPProvider(provider GeoIPProvider) TrafficPolicyEngineOption {
+	return func(tpe *TrafficPolicyEngine) {
+		tpe.geoProvider = provider
+	}
+}
+
+// WithPolicyLogger attaches a structured Logger that records deny/redirect
+// decisions made by EvaluateRequest.
+func WithPolicyLogger(logger Logger) TrafficPolicyEngineOption {
+	return func(tpe *TrafficPolicyEngine) {
+		tpe.logger = logger
+	}
 }
 
 // NewTrafficPolicyEngine creates a new traffic policy engine
-func NewTrafficPolicyEngine(backendMap map[string]interface{}) *TrafficPolicyEngine {
-	return &TrafficPolicyEngine{
+func NewTrafficPolicyEngine(backendMap map[string]interface{}, opts ...TrafficPolicyEngineOption) *TrafficPolicyEngine {
+	tpe := &TrafficPolicyEngine{
 		backendMap: backendMap,
 		policies:   make([]TrafficPolicy, 0),
+		ossMode:    true,
+		logger:     NewNoopLogger(),
 	}
+	for _, opt := range opts {
+		opt(tpe)
+	}
+	return tpe
 }
 
-// AddPolicy adds a new traffic policy
-func (tpe *TrafficPolicyEngine) AddPolicy(policy TrafficPolicy) {
+// AddPolicy adds a new traffic policy. It returns an error - without adding
+// the policy - when policy.Partition scopes it to a non-default admin
+// partition while the engine is running in OSS mode, matching Consul's own
+// enterprise/OSS split for partition-exports.
+func (tpe *TrafficPolicyEngine) AddPolicy(policy TrafficPolicy) error {
 	tpe.mutex.Lock()
 	defer tpe.mutex.Unlock()
-	
+
+	if tpe.ossMode && policy.Partition != "" && policy.Partition != "default" {
+		return fmt.Errorf("traffic policy %q: admin partitions require Consul Enterprise (engine is in OSS mode)", policy.Name)
+	}
+
 	policy.CreatedAt = time.Now()
 	policy.UpdatedAt = time.Now()
-	
+
 	tpe.policies = append(tpe.policies, policy)
-	
+
 	// Sort policies by priority (higher priority = higher precedence)
 	for i := 0; i < len(tpe.policies)-1; i++ {
 		for j := i + 1; j < len(tpe.policies); j++ {
@@ -86,8 +169,45 @@ func (tpe *TrafficPolicyEngine) AddPolicy(policy TrafficPolicy) {
 			}
 		}
 	}
-	
+
 	log.Printf("[INFO] Added traffic policy: %s (type: %s, priority: %d)", policy.Name, policy.Type, policy.Priority)
+	return nil
+}
+
+// ReplacePolicies atomically swaps the entire policy set for policies,
+// validating each one the same way AddPolicy does (rejecting the whole
+// batch - leaving the existing set untouched - if any policy fails OSS-mode
+// partition validation) before sorting by priority. Intended for an admin
+// "reload policies" operation where the caller already has the full
+// desired set, rather than adding one policy at a time.
+func (tpe *TrafficPolicyEngine) ReplacePolicies(policies []TrafficPolicy) error {
+	now := time.Now()
+	replacement := make([]TrafficPolicy, len(policies))
+	copy(replacement, policies)
+	for i, policy := range replacement {
+		if tpe.ossMode && policy.Partition != "" && policy.Partition != "default" {
+			return fmt.Errorf("traffic policy %q: admin partitions require Consul Enterprise (engine is in OSS mode)", policy.Name)
+		}
+		if policy.CreatedAt.IsZero() {
+			replacement[i].CreatedAt = now
+		}
+		replacement[i].UpdatedAt = now
+	}
+
+	for i := 0; i < len(replacement)-1; i++ {
+		for j := i + 1; j < len(replacement); j++ {
+			if replacement[i].Priority > replacement[j].Priority {
+				replacement[i], replacement[j] = replacement[j], replacement[i]
+			}
+		}
+	}
+
+	tpe.mutex.Lock()
+	tpe.policies = replacement
+	tpe.mutex.Unlock()
+
+	log.Printf("[INFO] Reloaded traffic policies: %d policies", len(replacement))
+	return nil
 }
 
 // EnablePolicy enables/disables a policy by name
@@ -106,41 +226,54 @@ func (tpe *TrafficPolicyEngine) EnablePolicy(name string, enabled bool) bool {
 	return false
 }
 
-// EvaluateRequest evaluates a request against all policies
-func (tpe *TrafficPolicyEngine) EvaluateRequest(r *http.Request) (interface{}, error) {
+// EvaluateRequest evaluates a request against all policies. The returned
+// policy name is the name of whichever policy matched (for metrics/logging
+// labels), or "" if none did.
+func (tpe *TrafficPolicyEngine) EvaluateRequest(r *http.Request) (interface{}, string, error) {
 	tpe.mutex.RLock()
 	defer tpe.mutex.RUnlock()
-	
+
+	reqPartition := tpe.getRequestPartition(r)
+	reqNamespace := tpe.getRequestNamespace(r)
+
 	// Check enabled policies in priority order
 	for _, policy := range tpe.policies {
 		if !policy.Enabled {
 			continue
 		}
-		
+		if !inScope(policy.Partition, policy.Namespace, reqPartition, reqNamespace) {
+			continue
+		}
+
 		match, action, backendURL := tpe.evaluatePolicy(r, policy)
 		if match {
 			if action == "deny" {
-				return nil, fmt.Errorf("request denied by policy: %s", policy.Name)
+				tpe.logger.Info("request denied by traffic policy", String("policy", policy.Name))
+				return nil, policy.Name, fmt.Errorf("request denied by policy: %s", policy.Name)
 			}
-			
+
 			if action == "redirect" && backendURL != "" {
 				if backend, exists := tpe.backendMap[backendURL]; exists {
 					log.Printf("[INFO] Request redirected by policy '%s' to backend: %s", policy.Name, backendURL)
-					return backend, nil
+					tpe.logger.Info("request redirected by traffic policy",
+						String("policy", policy.Name),
+						String("backend_url", backendURL),
+					)
+					return backend, policy.Name, nil
 				}
 			}
-			
+
             if action == "allow" {
                 if b := tpe.selectBackendByPolicy(r, policy); b != nil {
-                    return b, nil
+                    return b, policy.Name, nil
                 }
-                return nil, nil
+                return nil, policy.Name, nil
             }
 		}
 	}
-	
+
     // No policies matched or explicit backend chosen; let caller decide fallback
-    return nil, nil
+    return nil, "", nil
 }
 
 // evaluatePolicy checks if a request matches a single policy
@@ -163,9 +296,15 @@ func (tpe *TrafficPolicyEngine) evaluatePolicy(r *http.Request, policy TrafficPo
 
 // evaluateHeaderPolicy evaluates header-based policies
 func (tpe *TrafficPolicyEngine) evaluateHeaderPolicy(r *http.Request, policy TrafficPolicy) (bool, string, string) {
+	reqPartition := tpe.getRequestPartition(r)
+	reqNamespace := tpe.getRequestNamespace(r)
+
 	for _, rule := range policy.Rules {
+		if !inScope(rule.Partition, rule.Namespace, reqPartition, reqNamespace) {
+			continue
+		}
 		headerValue := r.Header.Get(rule.Field)
-		
+
 		switch rule.Operator {
 		case "equals":
 			if headerValue == rule.Value {
@@ -184,7 +323,11 @@ func (tpe *TrafficPolicyEngine) evaluateHeaderPolicy(r *http.Request, policy Tra
 	return false, "deny", ""
 }
 
-// evaluateGeoPolicy evaluates geolocation-based policies
+// evaluateGeoPolicy evaluates geolocation-based policies. "region" keeps its
+// original header/query/RFC1918-prefix heuristic; "country", "continent",
+// "city", "subdivision", and "asn" are resolved via a real MaxMind GeoIP2
+// lookup (tpe.geoProvider) and support the "equals", "in" (comma-separated
+// set), and "not_in" operators in addition to "contains".
 func (tpe *TrafficPolicyEngine) evaluateGeoPolicy(r *http.Request, policy TrafficPolicy) (bool, string, string) {
 	// Get region from various sources
 	region := r.Header.Get("X-Client-Region")
@@ -204,30 +347,121 @@ func (tpe *TrafficPolicyEngine) evaluateGeoPolicy(r *http.Request, policy Traffi
 			}
 		}
 	}
-	
+
+	reqPartition := tpe.getRequestPartition(r)
+	reqNamespace := tpe.getRequestNamespace(r)
+
+	var geo GeoInfo
+	var geoErr error
+	geoLoaded := false
+	lookupGeo := func() (GeoInfo, error) {
+		if geoLoaded {
+			return geo, geoErr
+		}
+		geoLoaded = true
+		if tpe.geoProvider == nil {
+			geoErr = fmt.Errorf("geoip: no provider configured")
+			return geo, geoErr
+		}
+		ip := net.ParseIP(tpe.getClientIP(r))
+		if ip == nil {
+			geoErr = fmt.Errorf("geoip: could not parse client IP")
+			return geo, geoErr
+		}
+		geo, geoErr = tpe.geoProvider.Lookup(ip)
+		return geo, geoErr
+	}
+
 	for _, rule := range policy.Rules {
-		if rule.Field == "region" {
-			switch rule.Operator {
-			case "equals":
-				if region == rule.Value {
-					return true, rule.Action, rule.Backend
-				}
-			case "contains":
-				if strings.Contains(region, rule.Value) {
-					return true, rule.Action, rule.Backend
-				}
+		if !inScope(rule.Partition, rule.Namespace, reqPartition, reqNamespace) {
+			continue
+		}
+
+		var value string
+		switch rule.Field {
+		case "region":
+			value = region
+		case "country":
+			g, err := lookupGeo()
+			if err != nil {
+				continue
+			}
+			value = g.Country
+		case "continent":
+			g, err := lookupGeo()
+			if err != nil {
+				continue
 			}
+			value = g.Continent
+		case "city":
+			g, err := lookupGeo()
+			if err != nil {
+				continue
+			}
+			value = g.City
+		case "subdivision":
+			g, err := lookupGeo()
+			if err != nil {
+				continue
+			}
+			value = g.Subdivision
+		case "asn":
+			g, err := lookupGeo()
+			if err != nil {
+				continue
+			}
+			value = strconv.FormatUint(uint64(g.ASN), 10)
+		default:
+			continue
+		}
+
+		if matchGeoValue(value, rule.Operator, rule.Value) {
+			return true, rule.Action, rule.Backend
 		}
 	}
-	
+
 	return false, "deny", ""
 }
 
+// matchGeoValue applies a geo rule's operator: "equals" and "contains" work
+// as elsewhere in this file; "in" and "not_in" treat ruleValue as a
+// comma-separated set.
+func matchGeoValue(value, operator, ruleValue string) bool {
+	switch operator {
+	case "equals":
+		return value == ruleValue
+	case "contains":
+		return strings.Contains(value, ruleValue)
+	case "in":
+		return geoValueInSet(value, ruleValue)
+	case "not_in":
+		return !geoValueInSet(value, ruleValue)
+	default:
+		return false
+	}
+}
+
+// geoValueInSet reports whether value equals one of the comma-separated
+// entries in set, ignoring surrounding whitespace around each entry.
+func geoValueInSet(value, set string) bool {
+	for _, candidate := range strings.Split(set, ",") {
+		if strings.TrimSpace(candidate) == value {
+			return true
+		}
+	}
+	return false
+}
+
 // evaluatePathPolicy evaluates path-based policies
 func (tpe *TrafficPolicyEngine) evaluatePathPolicy(r *http.Request, policy TrafficPolicy) (bool, string, string) {
 	path := r.URL.Path
-	
+	reqPartition := tpe.getRequestPartition(r)
+	reqNamespace := tpe.getRequestNamespace(r)
+
 	for _, rule := range policy.Rules {
+		if !inScope(rule.Partition, rule.Namespace, reqPartition, reqNamespace) {
+			continue
+		}
 		switch rule.Operator {
 		case "equals":
 			if path == rule.Value {
@@ -251,28 +485,68 @@ func (tpe *TrafficPolicyEngine) evaluatePathPolicy(r *http.Request, policy Traff
 	return false, "deny", ""
 }
 
-// evaluateCanaryPolicy evaluates canary deployment policies
+// evaluateCanaryPolicy evaluates canary deployment policies using a
+// consistent-hash cohort assignment: the request's CohortKey (a header,
+// cookie, or its IP) is hashed with FNV-1a 64-bit and mapped into one of
+// 10000 buckets, so a user's cohort stays stable across percentage changes
+// and unrelated request attributes, unlike hashing path|UA|IP directly.
 func (tpe *TrafficPolicyEngine) evaluateCanaryPolicy(r *http.Request, policy TrafficPolicy) (bool, string, string) {
-	// Simple canary implementation: percentage-based traffic splitting
-    if policy.Conditions.PercentageTraffic > 0 {
-		// Use request hash for consistent canary routing
-		hash := tpe.hashRequest(r)
-		modulo := hash % 100
-		
-        if int(modulo) < policy.Conditions.PercentageTraffic {
-            // Allow canary cohort; actual backend selection handled by caller
-            return true, "allow", ""
-        }
-	}
-    
+    if policy.Conditions.PercentageTraffic <= 0 {
+        return false, "deny", ""
+    }
+
+    key := tpe.extractCohortKey(r, policy.Conditions.CohortKey)
+    bucket := fnv64a(policy.Conditions.Salt+"|"+key) % 10000
+    threshold := uint64(policy.Conditions.PercentageTraffic * 100)
+
+    if bucket < threshold {
+        // Allow canary cohort; actual backend selection handled by caller
+        return true, "allow", ""
+    }
+
     return false, "deny", ""
 }
 
+// extractCohortKey resolves the value a canary policy hashes to assign a
+// request to a cohort. cohortKey is "header:<Name>", "cookie:<Name>", or
+// "ip"/"" (the default, falling back to the client IP).
+func (tpe *TrafficPolicyEngine) extractCohortKey(r *http.Request, cohortKey string) string {
+    switch {
+    case strings.HasPrefix(cohortKey, "header:"):
+        return r.Header.Get(strings.TrimPrefix(cohortKey, "header:"))
+    case strings.HasPrefix(cohortKey, "cookie:"):
+        if cookie, err := r.Cookie(strings.TrimPrefix(cohortKey, "cookie:")); err == nil {
+            return cookie.Value
+        }
+        return ""
+    default:
+        return tpe.getClientIP(r)
+    }
+}
+
+// fnv64a hashes s with FNV-1a 64-bit, used for consistent-hash cohort and
+// rendezvous-hash backend selection.
+func fnv64a(s string) uint64 {
+    h := fnv.New64a()
+    h.Write([]byte(s))
+    return h.Sum64()
+}
+
 // selectBackendByPolicy selects a backend based on policy rules
 func (tpe *TrafficPolicyEngine) selectBackendByPolicy(r *http.Request, policy TrafficPolicy) interface{} {
-    // If a rule specifies a concrete backend URL, return it
+    reqPartition := tpe.getRequestPartition(r)
+    reqNamespace := tpe.getRequestNamespace(r)
+
+    if policy.Conditions.RendezvousHash {
+        if backend := tpe.selectBackendByRendezvousHash(r, policy, reqPartition, reqNamespace); backend != nil {
+            return backend
+        }
+    }
+
+    // If a rule specifies a concrete backend URL, return it - skipping rules
+    // whose partition/namespace scope doesn't cover the request.
     for _, rule := range policy.Rules {
-        if rule.Backend != "" {
+        if rule.Backend != "" && inScope(rule.Partition, rule.Namespace, reqPartition, reqNamespace) {
             if backend, exists := tpe.backendMap[rule.Backend]; exists {
                 return backend
             }
@@ -282,20 +556,30 @@ func (tpe *TrafficPolicyEngine) selectBackendByPolicy(r *http.Request, policy Tr
     return nil
 }
 
-// hashRequest creates a consistent hash for the request
-func (tpe *TrafficPolicyEngine) hashRequest(r *http.Request) uint32 {
-	// Simple hash implementation for consistent routing
-	path := r.URL.Path
-	userAgent := r.Header.Get("User-Agent")
-	ip := tpe.getClientIP(r)
-	
-	// Combine multiple factors for better distribution
-	input := fmt.Sprintf("%s|%s|%s", path, userAgent, ip)
-	hash := uint32(0)
-	for _, c := range input {
-		hash = hash*31 + uint32(c)
-	}
-	return hash
+// selectBackendByRendezvousHash picks the in-scope rule backend with the
+// highest rendezvous (HRW) score for this request's cohort key, so scaling
+// the set of canary backends only remaps roughly 1/N of traffic instead of
+// reshuffling every cohort the way a plain modulo selection would.
+func (tpe *TrafficPolicyEngine) selectBackendByRendezvousHash(r *http.Request, policy TrafficPolicy, reqPartition, reqNamespace string) interface{} {
+    key := tpe.extractCohortKey(r, policy.Conditions.CohortKey)
+
+    var best interface{}
+    var bestScore uint64
+    var haveBest bool
+    for _, rule := range policy.Rules {
+        if rule.Backend == "" || !inScope(rule.Partition, rule.Namespace, reqPartition, reqNamespace) {
+            continue
+        }
+        backend, exists := tpe.backendMap[rule.Backend]
+        if !exists {
+            continue
+        }
+        score := fnv64a(policy.Conditions.Salt + "|" + key + "|" + rule.Backend)
+        if !haveBest || score > bestScore {
+            best, bestScore, haveBest = backend, score, true
+        }
+    }
+    return best
 }
 
 // getClientIP extracts client IP from request
@@ -313,6 +597,34 @@ func (tpe *TrafficPolicyEngine) getClientIP(r *http.Request) string {
     return r.RemoteAddr
 }
 
+// getRequestPartition extracts the caller's requested Consul admin partition
+// from the X-Consul-Partition header, defaulting to "default".
+func (tpe *TrafficPolicyEngine) getRequestPartition(r *http.Request) string {
+	if partition := r.Header.Get("X-Consul-Partition"); partition != "" {
+		return partition
+	}
+	return "default"
+}
+
+// getRequestNamespace extracts the caller's requested Consul namespace from
+// the X-Consul-Namespace header, defaulting to "" (no namespace scoping).
+func (tpe *TrafficPolicyEngine) getRequestNamespace(r *http.Request) string {
+	return r.Header.Get("X-Consul-Namespace")
+}
+
+// inScope reports whether a policy/rule scoped to scopePartition and
+// scopeNamespace applies to a request in reqPartition/reqNamespace. An empty
+// or "default" scopePartition matches every request; an empty scopeNamespace
+// matches every request too - both are treated as "not scoped" wildcards.
+func inScope(scopePartition, scopeNamespace, reqPartition, reqNamespace string) bool {
+	if scopePartition != "" && scopePartition != "default" && scopePartition != reqPartition {
+		return false
+	}
+	if scopeNamespace != "" && scopeNamespace != reqNamespace {
+		return false
+	}
+	return true
+}
 
 // GetPolicies returns all configured policies
 func (tpe *TrafficPolicyEngine) GetPolicies() []TrafficPolicy {
@@ -0,0 +1,116 @@
+package loadbalancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtractCohortKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-User-ID", "user-42")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "sess-7"})
+	r.RemoteAddr = "203.0.113.5:1234"
+
+	tpe := NewTrafficPolicyEngine(map[string]interface{}{})
+
+	if got := tpe.extractCohortKey(r, "header:X-User-ID"); got != "user-42" {
+		t.Fatalf("expected header cohort key %q, got %q", "user-42", got)
+	}
+	if got := tpe.extractCohortKey(r, "cookie:session"); got != "sess-7" {
+		t.Fatalf("expected cookie cohort key %q, got %q", "sess-7", got)
+	}
+	if got := tpe.extractCohortKey(r, "cookie:missing"); got != "" {
+		t.Fatalf("expected missing cookie to yield empty key, got %q", got)
+	}
+	if got := tpe.extractCohortKey(r, ""); got != "203.0.113.5" {
+		t.Fatalf("expected default cohort key to be client IP %q, got %q", "203.0.113.5", got)
+	}
+}
+
+func TestSelectBackendByRendezvousHashIsStableForSameKey(t *testing.T) {
+	backendMap := map[string]interface{}{
+		"canary-a": "canary-a",
+		"canary-b": "canary-b",
+		"canary-c": "canary-c",
+	}
+	tpe := NewTrafficPolicyEngine(backendMap)
+	policy := TrafficPolicy{
+		Rules: []PolicyRule{
+			{Backend: "canary-a"},
+			{Backend: "canary-b"},
+			{Backend: "canary-c"},
+		},
+		Conditions: PolicyConditions{
+			CohortKey:      "header:X-User-ID",
+			RendezvousHash: true,
+		},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-User-ID", "user-42")
+
+	first := tpe.selectBackendByRendezvousHash(r, policy, "", "")
+	for i := 0; i < 5; i++ {
+		got := tpe.selectBackendByRendezvousHash(r, policy, "", "")
+		if got != first {
+			t.Fatalf("expected rendezvous hash to pick the same backend every time for the same key, got %v then %v", first, got)
+		}
+	}
+}
+
+func TestSelectBackendByRendezvousHashRespectsScope(t *testing.T) {
+	backendMap := map[string]interface{}{
+		"canary-a": "canary-a",
+		"canary-b": "canary-b",
+	}
+	tpe := NewTrafficPolicyEngine(backendMap)
+	policy := TrafficPolicy{
+		Rules: []PolicyRule{
+			{Backend: "canary-a", Partition: "other-partition"},
+			{Backend: "canary-b"},
+		},
+		Conditions: PolicyConditions{
+			CohortKey:      "header:X-User-ID",
+			RendezvousHash: true,
+		},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-User-ID", "user-42")
+
+	got := tpe.selectBackendByRendezvousHash(r, policy, "default", "")
+	if got != "canary-b" {
+		t.Fatalf("expected the out-of-scope rule to be skipped and canary-b selected, got %v", got)
+	}
+}
+
+func TestSelectBackendByRendezvousHashDistributesAcrossKeys(t *testing.T) {
+	backendMap := map[string]interface{}{
+		"canary-a": "canary-a",
+		"canary-b": "canary-b",
+		"canary-c": "canary-c",
+	}
+	tpe := NewTrafficPolicyEngine(backendMap)
+	policy := TrafficPolicy{
+		Rules: []PolicyRule{
+			{Backend: "canary-a"},
+			{Backend: "canary-b"},
+			{Backend: "canary-c"},
+		},
+		Conditions: PolicyConditions{
+			CohortKey:      "header:X-User-ID",
+			RendezvousHash: true,
+		},
+	}
+
+	seen := make(map[interface{}]bool)
+	for i := 0; i < 50; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-User-ID", httptest.DefaultRemoteAddr+string(rune('a'+i%26))+string(rune('0'+i/26)))
+		seen[tpe.selectBackendByRendezvousHash(r, policy, "", "")] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expected rendezvous hashing to spread 50 distinct keys across more than one backend, got %v", seen)
+	}
+}
@@ -11,12 +11,20 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/tushar-xo/Load-Balancer-GO/controlplane"
 	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer" // Import our loadbalancer package
+	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer/accesslog"
+	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer/k8s"
+	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer/middleware/compress"
+	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer/provider"
+	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer/server"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -27,7 +35,8 @@ var (
 	serverPool = ServerPool{ // Initialize ServerPool with sticky sessions map
 		stickySessions: make(map[string]*Backend),
 	}
-	telemetry *loadbalancer.SimpleTelemetryProvider // Simple telemetry provider for observability
+	telemetry loadbalancer.TelemetryProvider // Telemetry provider for observability (simple or OTel-backed)
+	autoScaler *loadbalancer.AutoScaler       // EWMA-driven autoscaler, fed by lbHandler
 )
 
 func getClientRegion(r *http.Request) string {
@@ -94,12 +103,37 @@ var (
 		},
 		[]string{"backend"},
 	)
+
+	sessionsDroppedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "loadbalancer_sessions_dropped_total",
+			Help: "Sticky-session writes dropped because the Redis circuit breaker was open or the async queue was full",
+		},
+	)
 )
 
 func init() {
 	promRegistry.MustRegister(requestsTotal)
 	promRegistry.MustRegister(backendConnections)
 	promRegistry.MustRegister(requestDuration)
+	promRegistry.MustRegister(sessionsDroppedTotal)
+	serverPool.EnableBreakerRegistry(loadbalancer.NewBreakerRegistry(promRegistry, defaultBreakerOptions))
+}
+
+// defaultBreakerOptions is the BreakerRegistry's newOpts func. It reproduces
+// the tuning every backend source (Kubernetes, Consul, dynamic provider,
+// autoscaler) used to build its own standalone circuit breaker with, so
+// routing CircuitBreaker through the registry doesn't quietly loosen it to
+// all-default trip behavior.
+func defaultBreakerOptions(backendURL string) []loadbalancer.CircuitBreakerOption {
+	return []loadbalancer.CircuitBreakerOption{
+		loadbalancer.WithMaxRequests(3),
+		loadbalancer.WithInterval(10 * time.Second),
+		loadbalancer.WithTimeout(30 * time.Second),
+		loadbalancer.WithReadyToTrip(func(counts loadbalancer.Counts) bool {
+			return counts.ConsecutiveFailures >= 5
+		}),
+	}
 }
 
 type statusRecorder struct {
@@ -117,6 +151,21 @@ func (sr *statusRecorder) WriteHeader(code int) {
 func lbHandler(w http.ResponseWriter, r *http.Request) {
 	atomic.AddInt64(&requestCount, 1)
 
+	// TraceRequest starts a span for this request (joining an upstream trace
+	// if the client sent a traceparent header) and injects the propagated
+	// headers into r so the reverse proxy carries trace context to the
+	// backend; ctx replaces r's context for the rest of the handler so
+	// everything below correlates to this request's span.
+	ctx, span := telemetry.TraceRequest(r)
+	defer telemetry.EndSpan(span)
+	r = r.WithContext(ctx)
+
+	// requestLogger carries request_id (and, once known, session_id and
+	// selected_backend) on every subsequent line so a single request's log
+	// lines can be grep'd together.
+	requestID := fmt.Sprintf("req_%d", time.Now().UnixNano())
+	requestLogger := serverPool.log().With(loadbalancer.String("request_id", requestID))
+
 	// Check for sticky session cookie
 	var sessionID string
 	if cookie, err := r.Cookie("LOAD-BALANCING_SESSION"); err == nil {
@@ -133,17 +182,32 @@ func lbHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		http.SetCookie(w, cookie)
 	}
+	if sessionID != "" {
+		requestLogger = requestLogger.With(loadbalancer.String("session_id", sessionID))
+	}
+
+	// If DrainController evicted this session to rebalance it off an
+	// overloaded backend, reject it with a rebalance signal instead of
+	// routing it: the eviction already dropped its sticky assignment, so the
+	// client's reconnect will land on SelectBackend's current pick.
+	if sessionID != "" && serverPool.IsSessionDraining(sessionID) {
+		w.Header().Set("Connection", "close")
+		w.Header().Set("X-LB-Rebalance", "retry")
+		http.Error(w, "Service rebalancing, please retry", http.StatusServiceUnavailable)
+		return
+	}
 
 	// Get backend based on traffic policies, sticky session, or load balancing algorithm
 	var peer *Backend
+	var policyName string
 	region := getClientRegion(r)
-	
+
 	// Check if traffic policies are enabled and use them
 	if sessionID != "" {
 		peer = serverPool.GetBackendForStickySession(sessionID, region)
 	} else {
 		// Use traffic policies first, fall back to normal selection
-		peer = serverPool.SelectBackendWithPolicy(r)
+		peer, policyName = serverPool.SelectBackendWithPolicy(r)
 		if peer == nil {
 			peer = serverPool.SelectBackend(region)
 		}
@@ -156,9 +220,11 @@ func lbHandler(w http.ResponseWriter, r *http.Request) {
 
 	if peer == nil {
 		telemetry.LogError("No healthy backends available", fmt.Errorf("service unavailable"))
+		requestLogger.Error("no healthy backends available")
 		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
 		return
 	}
+	requestLogger = requestLogger.With(loadbalancer.String("selected_backend", peer.URL.String()))
 
 	// Log the routing decision with structured logging
 	telemetry.LogInfo("Routing request to backend",
@@ -166,11 +232,15 @@ func lbHandler(w http.ResponseWriter, r *http.Request) {
 		"session", sessionID,
 		"circuit_breaker_state", fmt.Sprintf("%v", peer.GetCircuitBreakerState()),
 	)
+	requestLogger.Info("routing request to backend",
+		loadbalancer.String("circuit_state", peer.GetCircuitBreakerState().String()),
+	)
 
 	peer.IncrementActive()
 	defer func() {
 		peer.DecrementActive()
 		backendConnections.WithLabelValues(peer.URL.String()).Set(float64(peer.ActiveConnections()))
+		serverPool.metrics.SetActiveConnections(peer.URL.String(), peer.ActiveConnections())
 	}()
 
 	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
@@ -190,13 +260,30 @@ func lbHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	success := statusCode < 500 && err == nil
 
+	// Attach what only this handler knows - the selected backend, its
+	// response time, sticky session, region, circuit state, and matched
+	// policy - to the access log entry accesslog.Middleware created, if the
+	// request was routed through it.
+	if entry := accesslog.FromContext(r.Context()); entry != nil {
+		entry.Backend = peer.URL.String()
+		entry.UpstreamDuration = duration
+		entry.SessionID = sessionID
+		entry.Region = region
+		entry.CircuitBreakerState = peer.GetCircuitBreakerState().String()
+		entry.PolicyName = policyName
+	}
+
 	// Record metrics
-	telemetry.RecordRequestMetrics(context.Background(), peer.URL.String(), r.Method, strconv.Itoa(statusCode), duration)
+	telemetry.RecordRequestMetrics(r.Context(), peer.URL.String(), r.Method, strconv.Itoa(statusCode), duration)
 	peer.RecordMetrics(duration, success)
+	if autoScaler != nil {
+		autoScaler.RecordRequest(duration)
+	}
 	
 	// Update legacy Prometheus metrics
 	requestsTotal.WithLabelValues(peer.URL.String(), strconv.Itoa(statusCode)).Inc()
 	requestDuration.WithLabelValues(peer.URL.String()).Observe(duration.Seconds())
+	serverPool.metrics.RecordRequest(peer.URL.String(), region, loadbalancer.StatusClass(statusCode), policyName)
 
 	// Handle circuit breaker failures
 	if err != nil {
@@ -204,7 +291,8 @@ func lbHandler(w http.ResponseWriter, r *http.Request) {
 			"backend", peer.URL.String(),
 			"error", err,
 		)
-		
+		requestLogger.Warn("circuit breaker triggered", loadbalancer.Err(err))
+
 		if errors.Is(err, loadbalancer.ErrTooManyRequests) {
 			http.Error(w, "Service temporarily unavailable (circuit breaker open)", http.StatusServiceUnavailable)
 		} else {
@@ -213,12 +301,41 @@ func lbHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	requestLogger.Debug("request completed", loadbalancer.Int("status_code", statusCode))
 	telemetry.LogDebug("Request completed successfully",
 		"backend", peer.URL.String(),
 		"duration", duration,
 	)
 }
 
+// serviceHandler routes a request to a backend selected via
+// SelectBackendForService rather than the pool-wide SelectBackend, so a
+// backend failing only one service's HealthCheckConfig (registered via
+// RegisterService) doesn't see its other traffic interrupted. The service
+// name comes from the "service" query parameter, defaulting to "default".
+func serviceHandler(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	if service == "" {
+		service = "default"
+	}
+	region := getClientRegion(r)
+
+	peer := serverPool.SelectBackendForService(service, region)
+	if peer == nil {
+		http.Error(w, fmt.Sprintf("Service unavailable: no healthy backend for service %q", service), http.StatusServiceUnavailable)
+		return
+	}
+
+	peer.IncrementActive()
+	defer func() {
+		peer.DecrementActive()
+		backendConnections.WithLabelValues(peer.URL.String()).Set(float64(peer.ActiveConnections()))
+		serverPool.metrics.SetActiveConnections(peer.URL.String(), peer.ActiveConnections())
+	}()
+
+	peer.ReverseProxy.ServeHTTP(w, r)
+}
+
 // dashboardHandler serves a web dashboard showing load balancer status and metrics
 func dashboardHandler(w http.ResponseWriter, r *http.Request) {
 	dashboardHTML := `
@@ -380,11 +497,46 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("No healthy backends"))
 }
 
+// autoscalerStatusHandler reports the autoscaler's current EWMA load signal,
+// thresholds, and instance bounds, for operators tuning AUTOSCALER_* env vars.
+func autoscalerStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if autoScaler == nil {
+		http.Error(w, "autoscaler not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(autoScaler.Status()); err != nil {
+		log.Printf("[ERROR] Failed to encode autoscaler status JSON: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
 // main is the entry point of the load balancer application
 func main() {
 	log.Printf("[INFO] Starting Go Load Balancer Application")
 	log.Printf("[INFO] Initializing backend servers...")
 
+	// Structured logger for backend state transitions, circuit breaker
+	// transitions, and rate-limit rejections (LOG_LEVEL/LOG_FORMAT/
+	// LOG_SAMPLING_ENABLED; see loadbalancer.NewLogger). Falls back to a
+	// no-op logger on init failure so a bad LOG_LEVEL value can't take the
+	// load balancer down. Initialized first since the static backend loop
+	// below wires it into each circuit breaker.
+	appLogger, err := loadbalancer.NewLogger("go-loadbalancer")
+	if err != nil {
+		log.Printf("[ERROR] Failed to initialize structured logger, falling back to no-op: %v", err)
+		appLogger = loadbalancer.NewNoopLogger()
+	}
+
+	// lb_* Prometheus metrics (requests, durations, backend state, rate
+	// limiting, sticky sessions), registered on the same registry as the
+	// legacy loadbalancer_* collectors below. Initialized before
+	// serverPool.EnableMetrics/AddBackend so every backend picks it up from
+	// the start.
+	lbMetrics := loadbalancer.NewLBMetrics(promRegistry)
+	serverPool.EnableMetrics(lbMetrics)
+
 	// Initialize backend servers with different weights for demonstration
 	backendConfigs := []struct {
 		url    string
@@ -430,17 +582,19 @@ func main() {
 			}),
 			loadbalancer.WithOnStateChange(func(name string, from, to loadbalancer.CircuitBreakerState) {
 				log.Printf("[INFO] Circuit breaker '%s' changed from %v to %v", name, from, to)
+				lbMetrics.SetCircuitBreakerState(name, to)
 			}),
+			loadbalancer.WithLogger(appLogger),
 		)
-		
+
         backend := &Backend{
 			URL:            u,
-			Alive:          true,
 			ReverseProxy:   proxy,
 			Weight:         config.weight,
 			Region:         config.region,
 			CircuitBreaker: circuitBreaker,
 		}
+		backend.SetAlive(true)
 		serverPool.AddBackend(backend)
 		log.Printf("[INFO] Added backend: %s (weight: %d)", config.url, config.weight)
 		backendConnections.WithLabelValues(backend.URL.String()).Set(0)
@@ -448,18 +602,100 @@ func main() {
 		requestDuration.WithLabelValues(backend.URL.String()).Observe(0)
 	}
 
-	// Enable Redis support with mock client for demonstration
-	redisClient := loadbalancer.NewMockRedisClient()
-	serverPool.EnableRedisSupport(redisClient, "loadbalancer", time.Hour)
-	log.Printf("[INFO] Redis support enabled for distributed sticky sessions")
-	
+	// Pick the session store backend. SESSION_STORE=redis requires REDIS_*
+	// env vars (see LoadRedisConfigFromEnv); SESSION_STORE=leveldb persists to
+	// SESSION_STORE_PATH; anything else (including unset) runs an in-memory
+	// LRU store sized by SESSION_STORE_CAPACITY, suitable for single-node dev.
+	sessionBackend := loadbalancer.StoreBackend(os.Getenv("SESSION_STORE"))
+	var redisCfg *loadbalancer.RedisConfig
+	if sessionBackend == loadbalancer.StoreBackendRedis {
+		cfg := loadbalancer.LoadRedisConfigFromEnv()
+		redisCfg = &cfg
+	}
+	levelDBPath := os.Getenv("SESSION_STORE_PATH")
+	if levelDBPath == "" {
+		levelDBPath = "./data/sessions"
+	}
+	memoryCapacity, _ := strconv.Atoi(os.Getenv("SESSION_STORE_CAPACITY"))
+
+	sessionStore, err := loadbalancer.NewSessionStore(sessionBackend, redisCfg, levelDBPath, memoryCapacity)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to initialize session store: %v", err)
+	}
+	serverPool.EnableRedisSupport(sessionStore, "loadbalancer", time.Hour)
+	log.Printf("[INFO] Session store enabled for sticky sessions (backend: %s)", sessionBackend)
+
+	if os.Getenv("SESSION_ASYNC_WRITES") == "true" {
+		serverPool.EnableAsyncSessionWrites(1000, 4, 800, sessionsDroppedTotal)
+	}
+
+	autoscalerLeaderElectionEnabled := os.Getenv("AUTOSCALER_LEADER_ELECTION") == "true"
+	if autoscalerLeaderElectionEnabled {
+		replicaID := os.Getenv("REPLICA_ID")
+		if replicaID == "" {
+			replicaID, _ = os.Hostname()
+		}
+		serverPool.EnableLeaderElection(context.Background(), replicaID, 15*time.Second, 5*time.Second)
+	}
+
+	if os.Getenv("CLUSTER_BUS_ENABLED") == "true" {
+		clusterBus := loadbalancer.NewClusterBus(sessionStore, "loadbalancer")
+		serverPool.EnableClusterBus(context.Background(), clusterBus)
+	}
+
+	if os.Getenv("SESSION_REBALANCING_ENABLED") == "true" {
+		tolerance := 0.2
+		if v, err := strconv.ParseFloat(os.Getenv("SESSION_REBALANCE_TOLERANCE"), 64); err == nil {
+			tolerance = v
+		}
+		drainRate := 1.0
+		if v, err := strconv.ParseFloat(os.Getenv("SESSION_REBALANCE_RATE"), 64); err == nil {
+			drainRate = v
+		}
+		interval := 10 * time.Second
+		if v, err := strconv.Atoi(os.Getenv("SESSION_REBALANCE_INTERVAL_SECONDS")); err == nil && v > 0 {
+			interval = time.Duration(v) * time.Second
+		}
+		serverPool.EnableSessionRebalancing(context.Background(), loadbalancer.RebalanceOptions{
+			Tolerance: tolerance,
+			DrainRate: drainRate,
+			Interval:  interval,
+		})
+	}
+
 	// Enable Consul service discovery (can be toggled with environment variable)
 	consulEnabled := os.Getenv("CONSUL_ENABLED") == "true"
 	if consulEnabled {
-		consulClient := loadbalancer.NewMockConsulClient()
-		consulManager := loadbalancer.NewConsulServiceManager(consulClient, "web-app")
+		var consulClient loadbalancer.ConsulClient
+		if consulAddr := os.Getenv("CONSUL_ADDR"); consulAddr != "" {
+			var opts []loadbalancer.RealConsulClientOption
+			if token := os.Getenv("CONSUL_TOKEN"); token != "" {
+				opts = append(opts, loadbalancer.WithConsulToken(token))
+			}
+			if dc := os.Getenv("CONSUL_DATACENTER"); dc != "" {
+				opts = append(opts, loadbalancer.WithConsulDatacenter(dc))
+			}
+			if partition := os.Getenv("CONSUL_PARTITION"); partition != "" {
+				opts = append(opts, loadbalancer.WithConsulPartition(partition))
+			}
+			if namespace := os.Getenv("CONSUL_NAMESPACE"); namespace != "" {
+				opts = append(opts, loadbalancer.WithConsulNamespace(namespace))
+			}
+			if tag := os.Getenv("CONSUL_TAG"); tag != "" {
+				opts = append(opts, loadbalancer.WithConsulTag(tag))
+			}
+			consulClient = loadbalancer.NewRealConsulClient(consulAddr, opts...)
+		} else {
+			consulClient = loadbalancer.NewMockConsulClient()
+		}
+
+		var consulMgrOpts []loadbalancer.ConsulServiceManagerOption
+		if pollInterval, err := time.ParseDuration(os.Getenv("CONSUL_WATCH_POLL_INTERVAL")); err == nil && pollInterval > 0 {
+			consulMgrOpts = append(consulMgrOpts, loadbalancer.WithPollInterval(pollInterval))
+		}
+		consulManager := loadbalancer.NewConsulServiceManager(consulClient, "web-app", consulMgrOpts...)
 		serverPool.EnableConsulSupport(consulManager)
-		
+
 		// Add initial Consul-discovered backends
 		serverPool.UpdateBackendsFromConsul()
 		log.Printf("[INFO] Consul service discovery enabled")
@@ -467,10 +703,92 @@ func main() {
 		log.Printf("[INFO] Using static backend configuration (set CONSUL_ENABLED=true for service discovery)")
 	}
 
-	// Initialize Simple Telemetry for observability
-	telemetry = loadbalancer.NewSimpleTelemetryProvider("go-loadbalancer")
-	log.Printf("[INFO] Simple telemetry provider enabled")
-	
+	// Enable the generic file-based dynamic configuration provider - it
+	// watches FILE_PROVIDER_PATH and the pool's backend membership tracks it
+	// without a restart. Kubernetes has its own dedicated integration below
+	// (EnableKubernetesSupport) rather than going through provider.Provider;
+	// a DockerProvider is built the same way (provider.NewDockerProvider)
+	// once a *client.Client is available.
+	if filePath := os.Getenv("FILE_PROVIDER_PATH"); filePath != "" {
+		providers := map[string]provider.Provider{
+			"file": provider.NewFileProvider("file", filePath),
+		}
+		if err := serverPool.EnableDynamicProviders(context.Background(), provider.NewAggregator(500*time.Millisecond), providers); err != nil {
+			log.Printf("[ERROR] Failed to enable dynamic config providers: %v", err)
+		} else {
+			log.Printf("[INFO] Dynamic file provider enabled (path=%s)", filePath)
+		}
+	}
+
+	// Enable Kubernetes EndpointSlice service discovery (K8S_ENABLED=true).
+	// K8S_NAMESPACE/K8S_SERVICE select the Service to watch; K8S_PORT_NAME
+	// picks its port when it exposes more than one, and KUBECONFIG picks an
+	// out-of-cluster config file when not running inside a pod.
+	if os.Getenv("K8S_ENABLED") == "true" {
+		clientset, err := k8s.NewClientset(os.Getenv("KUBECONFIG"))
+		if err != nil {
+			log.Printf("[ERROR] Failed to build Kubernetes client: %v", err)
+		} else {
+			namespace := os.Getenv("K8S_NAMESPACE")
+			if namespace == "" {
+				namespace = "default"
+			}
+			serviceName := os.Getenv("K8S_SERVICE")
+			manager := k8s.NewServiceManager(clientset, namespace, serviceName, os.Getenv("K8S_PORT_NAME"))
+			serverPool.EnableKubernetesSupport(manager)
+			log.Printf("[INFO] Kubernetes service discovery enabled (namespace=%s, service=%s)", namespace, serviceName)
+		}
+	}
+
+	// Initialize telemetry. Set OTEL_ENABLED=true to export traces/metrics/logs
+	// via OTLP instead of the stdlib-log-only SimpleTelemetryProvider.
+	if os.Getenv("OTEL_ENABLED") == "true" {
+		otelProvider, err := loadbalancer.NewOTelTelemetryProvider(context.Background(), "go-loadbalancer")
+		if err != nil {
+			log.Printf("[ERROR] Failed to initialize OTel telemetry provider, falling back to simple: %v", err)
+			telemetry = loadbalancer.NewSimpleTelemetryProvider("go-loadbalancer")
+		} else {
+			telemetry = otelProvider
+			log.Printf("[INFO] OpenTelemetry telemetry provider enabled")
+		}
+	} else {
+		telemetry = loadbalancer.NewSimpleTelemetryProvider("go-loadbalancer")
+		log.Printf("[INFO] Simple telemetry provider enabled")
+	}
+
+	serverPool.EnableLogger(appLogger)
+
+	// Enable active HTTP health probing and passive outlier ejection by
+	// setting HEALTH_CHECK_HTTP_PATH (e.g. "/healthz"). Left unset,
+	// HealthCheck keeps doing its original net.DialTimeout check.
+	if healthPath := os.Getenv("HEALTH_CHECK_HTTP_PATH"); healthPath != "" {
+		serverPool.EnableHealthChecks(loadbalancer.HealthCheckOptions{Path: healthPath})
+		log.Printf("[INFO] Active HTTP health checks enabled (path=%s)", healthPath)
+	}
+
+	// Register a "default" Service whose per-backend health is tracked
+	// independently of the pool-wide HealthCheck above, and routable via
+	// /service (see serviceHandler). Set SERVICE_HEALTH_CHECK_PATH (e.g.
+	// "/healthz") to opt in; left unset, RegisterService is never called and
+	// /service always reports no healthy backend.
+	if servicePath := os.Getenv("SERVICE_HEALTH_CHECK_PATH"); servicePath != "" {
+		serverPool.RegisterService("default", loadbalancer.HealthCheckConfig{Path: servicePath}, serverPool.Backends())
+		log.Printf("[INFO] Service health checks enabled for service \"default\" (path=%s)", servicePath)
+	}
+
+	// Set the default backend selection strategy (SELECTION_STRATEGY=
+	// round_robin|weighted|least_loaded|p2c). Left unset, SelectBackend keeps
+	// its original Weighted behavior; a TrafficPolicy's own Strategy field
+	// can still override this per request.
+	switch os.Getenv("SELECTION_STRATEGY") {
+	case "round_robin":
+		serverPool.SetSelectionStrategy(loadbalancer.RoundRobin)
+	case "least_loaded":
+		serverPool.SetSelectionStrategy(loadbalancer.LeastLoaded)
+	case "p2c":
+		serverPool.SetSelectionStrategy(loadbalancer.P2C)
+	}
+
 	// Enable traffic policies (can be toggled with environment variable)
 	trafficPoliciesEnabled := os.Getenv("TRAFFIC_POLICIES_ENABLED") == "true"
 	if trafficPoliciesEnabled {
@@ -542,22 +860,111 @@ func main() {
 			},
 		}
 		
-		serverPool.EnableTrafficPolicies(policies)
+		// Partitions are a Consul Enterprise feature; only allow
+		// partition-scoped policies when one was actually configured above.
+		ossMode := os.Getenv("CONSUL_PARTITION") == ""
+		tpeOpts := []loadbalancer.TrafficPolicyEngineOption{loadbalancer.WithOSSMode(ossMode), loadbalancer.WithPolicyLogger(appLogger)}
+
+		// Enable real country/continent/asn/city/subdivision geo policies by
+		// pointing at a MaxMind GeoIP2/GeoLite2 mmdb database.
+		if cityDBPath := os.Getenv("GEOIP_CITY_DB"); cityDBPath != "" {
+			geoProvider, err := loadbalancer.NewMaxMindGeoIPProvider(cityDBPath, os.Getenv("GEOIP_ASN_DB"))
+			if err != nil {
+				log.Printf("[ERROR] Failed to load GeoIP database: %v", err)
+			} else {
+				tpeOpts = append(tpeOpts, loadbalancer.WithGeoIPProvider(geoProvider))
+				log.Printf("[INFO] GeoIP provider enabled (city db: %s)", cityDBPath)
+			}
+		}
+
+		serverPool.EnableTrafficPolicies(policies, tpeOpts...)
 		log.Printf("[INFO] Traffic policies engine enabled with %d policies", len(policies))
 	} else {
 		log.Printf("[INFO] Using standard load balancing (set TRAFFIC_POLICIES_ENABLED=true for policies)")
 	}
 
+	// Enable the gRPC control-plane API (policy management + service discovery
+	// introspection) on a separate listener, toggled independently of the HTTP
+	// server above.
+	if os.Getenv("CONTROLPLANE_GRPC_ENABLED") == "true" {
+		if serverPool.trafficPolicyEngine == nil {
+			serverPool.trafficPolicyEngine = loadbalancer.NewTrafficPolicyEngine(serverPool.createBackendMap())
+		}
+
+		grpcAddr := os.Getenv("CONTROLPLANE_GRPC_ADDR")
+		if grpcAddr == "" {
+			grpcAddr = ":9090"
+		}
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("[ERROR] Failed to listen for control-plane gRPC on %s: %v", grpcAddr, err)
+		}
+		cpServer := controlplane.NewServer(serverPool.trafficPolicyEngine, serverPool.consulManager)
+		grpcServer := cpServer.NewGRPCServer()
+		go func() {
+			log.Printf("[INFO] Control-plane gRPC server listening on %s", grpcAddr)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Printf("[ERROR] Control-plane gRPC server stopped: %v", err)
+			}
+		}()
+	}
+
 	log.Printf("[INFO] Registered %d backends", len(backendConfigs))
 
+	// Configure the push-driven autoscaler: AUTOSCALER_PROVISIONER selects
+	// what a scale-up actually provisions ("mock" for an in-process mock
+	// server, suitable for local dev/demo; "consul" registers it with a
+	// Consul agent so ConsulServiceManager's next watch tick picks it up;
+	// "docker" uses the Docker provisioner, which logs that it isn't
+	// implemented and fails scale-ups; anything else falls back to "mock").
+	autoscalerThreshold, err := strconv.ParseInt(os.Getenv("AUTOSCALER_THRESHOLD"), 10, 64)
+	if err != nil || autoscalerThreshold <= 0 {
+		autoscalerThreshold = 20
+	}
+	autoscalerMin, err := strconv.Atoi(os.Getenv("AUTOSCALER_MIN_INSTANCES"))
+	if err != nil || autoscalerMin <= 0 {
+		autoscalerMin = len(backendConfigs)
+	}
+	autoscalerMax, err := strconv.Atoi(os.Getenv("AUTOSCALER_MAX_INSTANCES"))
+	if err != nil || autoscalerMax <= 0 {
+		autoscalerMax = autoscalerMin + 5
+	}
+	mockProvisionerBase, err := strconv.Atoi(os.Getenv("AUTOSCALER_MOCK_PORT_BASE"))
+	if err != nil || mockProvisionerBase <= 0 {
+		mockProvisionerBase = 9000
+	}
+
+	var provisioner loadbalancer.BackendProvisioner = loadbalancer.NewMockServerProvisioner(mockProvisionerBase)
+	switch os.Getenv("AUTOSCALER_PROVISIONER") {
+	case "consul":
+		consulAgentAddr := os.Getenv("CONSUL_ADDR")
+		if consulAgentAddr == "" {
+			consulAgentAddr = "http://localhost:8500"
+		}
+		provisioner = loadbalancer.NewConsulAgentBackendProvisioner(consulAgentAddr, "web-app", provisioner)
+		log.Printf("[INFO] Autoscaler provisioning via Consul agent at %s", consulAgentAddr)
+	case "docker":
+		provisioner = loadbalancer.NewDockerBackendProvisioner(os.Getenv("AUTOSCALER_DOCKER_IMAGE"), os.Getenv("AUTOSCALER_DOCKER_NETWORK"))
+		log.Printf("[WARN] Autoscaler provisioning via Docker is not implemented yet; scale-up will fail until AUTOSCALER_PROVISIONER is changed")
+	default:
+		log.Printf("[INFO] Autoscaler provisioning mock backends starting at port %d", mockProvisionerBase)
+	}
+
+	autoScaler = loadbalancer.NewAutoScaler(&serverPool, provisioner, autoscalerThreshold, autoscalerMin, autoscalerMax)
+	log.Printf("[INFO] Autoscaler enabled (threshold=%d min=%d max=%d)", autoscalerThreshold, autoscalerMin, autoscalerMax)
+
 	// Setup HTTP routes
 	rateLimiter := loadbalancer.NewRateLimiter(10, 5)
+	rateLimiter.EnableLogger(appLogger)
+	rateLimiter.EnableMetrics(lbMetrics, "ip")
 
-	http.HandleFunc("/", dashboardHandler)                                  // Dashboard interface
-	http.Handle("/lb", rateLimiter.Middleware(http.HandlerFunc(lbHandler))) // Load balancing endpoint
-	http.HandleFunc("/metrics", metricsHandler)                             // JSON metrics endpoint
-	http.HandleFunc("/health", healthCheckHandler)                          // Health check for K8s probes
-	http.Handle("/prometheus", promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{})) // Prometheus metrics
+	http.HandleFunc("/", dashboardHandler)                                                                                 // Dashboard interface
+	http.Handle("/lb", compress.Compress(rateLimiter.Middleware(http.HandlerFunc(lbHandler)), compress.CompressOptions{})) // Load balancing endpoint
+	http.HandleFunc("/metrics", metricsHandler)                                                                            // JSON metrics endpoint
+	http.HandleFunc("/health", healthCheckHandler)                                                                         // Health check for K8s probes
+	http.HandleFunc("/autoscaler/status", autoscalerStatusHandler)                                                         // Autoscaler EWMA/threshold status
+	http.HandleFunc("/service", serviceHandler)                                                                           // Service-scoped routing (see RegisterService)
+	http.Handle("/prometheus", promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{}))                                  // Prometheus metrics
 
 	log.Printf("[INFO] Load balancer starting on :8080")
 	log.Printf("[INFO] Available endpoints:")
@@ -565,16 +972,89 @@ func main() {
 	log.Printf("[INFO]   - Load balancer: http://localhost:8080/lb")
 	log.Printf("[INFO]   - Metrics: http://localhost:8080/metrics")
 	log.Printf("[INFO]   - Health: http://localhost:8080/health")
+	log.Printf("[INFO]   - Autoscaler status: http://localhost:8080/autoscaler/status")
+	log.Printf("[INFO]   - Service-scoped routing: http://localhost:8080/service")
 	log.Printf("[INFO]   - Prometheus: http://localhost:8080/prometheus")
 
 	// Start background services
 	go loadbalancer.HealthCheckLoop(&serverPool)
-	go loadbalancer.AutoScalerLoop(&requestCount, &serverPool)
+	switch {
+	case autoscalerLeaderElectionEnabled:
+		go loadbalancer.AutoScalerLoop(autoScaler)
+	case os.Getenv("AUTOSCALER_SINGLE_INSTANCE") == "true":
+		serverPool.EnableSoleInstanceAutoScaling()
+		go loadbalancer.AutoScalerLoop(autoScaler)
+	default:
+		log.Printf("[WARN] Autoscaler loop not started: TryScale has no leader lease to act under. Set AUTOSCALER_LEADER_ELECTION=true for multi-replica deployments or AUTOSCALER_SINGLE_INSTANCE=true if this is the only replica")
+	}
 
 	log.Printf("[INFO] Load balancer is ready to accept connections")
 
-	// Start the HTTP server with default ServeMux
-	if err := http.ListenAndServe(":8080", nil); err != nil {
+	// Multiplex HTTP, HTTPS, and gRPC (health checks + the admin API) onto the
+	// same :8080 listener via cmux, so operators don't need a second port for
+	// the control plane. TLS is only enabled if mTLS env vars are set; the
+	// admin API works against the live serverPool regardless of the separate
+	// CONTROLPLANE_GRPC_ENABLED listener above.
+	tlsCfg, err := loadbalancer.NewMTLSServerFromEnv()
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to build server TLS config: %v", err)
+	}
+
+	// RegisterMetrics is required once per process regardless of which gRPC
+	// listener(s) end up serving: the always-on cmux-multiplexed grpcServer
+	// built below counts active RPCs via the same interceptors as the
+	// optional CONTROLPLANE_GRPC_ENABLED listener above, so lb_grpc_active_rpcs
+	// must be registered even when that env var is unset.
+	controlplane.RegisterMetrics(promRegistry)
+
+	adminServer := controlplane.NewServer(serverPool.trafficPolicyEngine, serverPool.consulManager).WithAdminPool(&serverPool)
+	grpcServer := adminServer.NewGRPCServer()
+
+	var rootHandler http.Handler = http.DefaultServeMux
+	var accessLogWriter *accesslog.Writer
+	if os.Getenv("ACCESS_LOG_ENABLED") == "true" {
+		accessLogPath := os.Getenv("ACCESS_LOG_FILE")
+		if accessLogPath == "" {
+			accessLogPath = "access.log"
+		}
+		accessLogFormat := accesslog.Format(os.Getenv("ACCESS_LOG_FORMAT"))
+		if accessLogFormat == "" {
+			accessLogFormat = accesslog.Common
+		}
+		accessLogWriter, err = accesslog.NewWriter(accesslog.Config{
+			Format:   accessLogFormat,
+			FilePath: accessLogPath,
+		})
+		if err != nil {
+			log.Fatalf("[ERROR] Failed to start access log writer: %v", err)
+		}
+		accessLogWriter.EnableMetrics(accesslog.NewMetrics(promRegistry))
+		rootHandler = accesslog.Middleware(rootHandler, accessLogWriter)
+		log.Printf("[INFO] Access logging enabled: %s (format=%s)", accessLogPath, accessLogFormat)
+	}
+
+	mux, err := server.New(server.Options{
+		Addr:        ":8080",
+		HTTPHandler: rootHandler,
+		GRPCServer:  grpcServer,
+		TLSConfig:   tlsCfg,
+		Pool:        &serverPool,
+	})
+	if err != nil {
 		log.Fatalf("[ERROR] Failed to start server: %v", err)
 	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	<-sigCh
+
+	log.Printf("[INFO] Shutting down: draining in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := mux.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[ERROR] Error during shutdown: %v", err)
+	}
+	if accessLogWriter != nil {
+		accessLogWriter.Close()
+	}
 }
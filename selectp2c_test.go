@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func newBackendWithLatency(t *testing.T, rawurl string, weight int, latencyEWMA float64) *Backend {
+	t.Helper()
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatalf("failed to parse url %q: %v", rawurl, err)
+	}
+	backend := &Backend{URL: u, Weight: weight, latencyEWMA: latencyEWMA, successEWMA: 1}
+	backend.SetAlive(true)
+	return backend
+}
+
+func TestSelectP2CReturnsOnlyCandidateWhenAlone(t *testing.T) {
+	only := newBackendWithLatency(t, "http://backend-a", 1, 0.1)
+	if got := selectP2C([]*Backend{only}); got != only {
+		t.Fatalf("expected the sole candidate to be returned, got %v", got)
+	}
+}
+
+func TestSelectP2CPrefersLowerScore(t *testing.T) {
+	good := newBackendWithLatency(t, "http://backend-good", 1, 0.01)
+	bad := newBackendWithLatency(t, "http://backend-bad", 1, 10)
+	candidates := []*Backend{good, bad}
+
+	// Both are always sampled since there are only two candidates, so P2C
+	// degenerates into a straight comparison: it must never pick the
+	// higher-scoring backend.
+	for i := 0; i < 20; i++ {
+		if got := selectP2C(candidates); got != good {
+			t.Fatalf("expected selectP2C to always prefer the lower-score backend, got %v", got.URL)
+		}
+	}
+}
+
+func TestWeightedSampleIndexExcludesGivenIndex(t *testing.T) {
+	candidates := []*Backend{
+		newBackendWithLatency(t, "http://backend-0", 1, 0.1),
+		newBackendWithLatency(t, "http://backend-1", 1, 0.1),
+	}
+	for i := 0; i < 20; i++ {
+		got := weightedSampleIndex(candidates, 0)
+		if got == 0 {
+			t.Fatalf("expected weightedSampleIndex to never return the excluded index, got %d", got)
+		}
+	}
+}
+
+func TestWeightedSampleIndexReachesZeroWeightCandidates(t *testing.T) {
+	// sampleWeight treats weight < 1 as 1, so even a zero-weight backend
+	// must still be reachable.
+	candidates := []*Backend{
+		newBackendWithLatency(t, "http://backend-0", 0, 0.1),
+		newBackendWithLatency(t, "http://backend-1", 0, 0.1),
+	}
+	seen := make(map[int]bool)
+	for i := 0; i < 50; i++ {
+		seen[weightedSampleIndex(candidates, -1)] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected both zero-weight candidates to be reachable, got indices %v", seen)
+	}
+}
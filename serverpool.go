@@ -5,40 +5,116 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"math/rand"
 	"net"
     "net/http"
 	"net/http/httputil"
 	"net/url"
+	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer"
+	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer/k8s"
+	"github.com/tushar-xo/Load-Balancer-GO/loadbalancer/provider"
 )
 
 type Backend struct {
-	URL            *url.URL
-	Alive          bool
+	URL  *url.URL
+	// alive is read on every selection-path call (GetNextPeer,
+	// GetNextPeerWeighted, Score, ...), so it's a plain atomic.Bool rather
+	// than living behind mux: HealthCheck flipping it shouldn't serialize
+	// with hot routing reads.
+	alive          atomic.Bool
 	mux            sync.RWMutex
 	ReverseProxy   *httputil.ReverseProxy
 	Weight         int // Weight for weighted routing (higher = more traffic)
 	Region         string
+	// ProbeType selects which loadbalancer.HealthProbe probeBackend uses for
+	// this backend: "http" (the default, including ""), "tcp", or "grpc" -
+	// so a pool can mix backend kinds, e.g. a gRPC service alongside HTTP
+	// ones. ProbeConfig carries that probe's per-backend settings.
+	ProbeType      string
+	ProbeConfig    loadbalancer.ProbeConfig
 	latencyEWMA    float64
 	successEWMA    float64
 	active         int64
 	CircuitBreaker *loadbalancer.CircuitBreaker // Circuit breaker for fault tolerance
+
+	// probeLatencyEWMA tracks active HTTP health-probe latency, separate
+	// from latencyEWMA (which is derived from proxied request traffic, not
+	// probes) - see recordProbeLatency.
+	probeLatencyEWMA float64
+
+	// errorWindow holds recent RecordMetrics outcomes for passive outlier
+	// ejection; errorRate trims samples older than its window on each call
+	// rather than on every RecordMetrics (which doesn't know the window
+	// duration - that's pool-level HealthCheckOptions config).
+	errorWindow []errorSample
+
+	// ejectedUntil is non-zero while this backend is passively ejected; it's
+	// reinstated (probed again) once time.Now() passes it. ejectionCount is
+	// the number of times it's been ejected so far and never resets, so
+	// repeated ejections keep doubling the backoff per HealthCheckOptions.
+	ejectedUntil  time.Time
+	ejectionCount int
+
+	// metrics is set by ServerPool.AddBackend (from the pool's EnableMetrics
+	// call, if any) so RecordMetrics can update lb_request_duration_seconds
+	// without Backend needing its own EnableMetrics call.
+	metrics *loadbalancer.LBMetrics
+
+	// serviceAlive holds this backend's health as seen by each Service it
+	// was registered into via ServerPool.RegisterService, keyed by service
+	// name - separate from alive (the pool-wide bit HealthCheck maintains)
+	// so a probe failure against one service's HealthCheckConfig doesn't
+	// evict the backend from an unrelated service sharing the same URL.
+	serviceMu    sync.RWMutex
+	serviceAlive map[string]bool
+}
+
+// errorSample is one RecordMetrics outcome, timestamped for errorRate's
+// sliding-window trim.
+type errorSample struct {
+	at      time.Time
+	success bool
 }
 
 func (b *Backend) SetAlive(alive bool) {
-	b.mux.Lock()
-	b.Alive = alive
-	b.mux.Unlock()
+	b.alive.Store(alive)
 }
 
 func (b *Backend) IsAlive() bool {
-	b.mux.RLock()
-	defer b.mux.RUnlock()
-	return b.Alive
+	return b.alive.Load()
+}
+
+// SetAliveForService records this backend's health as seen by service,
+// independent of the pool-wide alive bit SetAlive maintains. Called from
+// HealthCheckManager.OnUpdate via ServerPool.RegisterService.
+func (b *Backend) SetAliveForService(service string, alive bool) {
+	b.serviceMu.Lock()
+	if b.serviceAlive == nil {
+		b.serviceAlive = make(map[string]bool)
+	}
+	b.serviceAlive[service] = alive
+	b.serviceMu.Unlock()
+}
+
+// IsAliveForService reports whether this backend is currently healthy for
+// service. A backend never registered into service (e.g. it only serves
+// other services, or RegisterService for service hasn't reported yet) falls
+// back to IsAlive.
+func (b *Backend) IsAliveForService(service string) bool {
+	b.serviceMu.RLock()
+	alive, ok := b.serviceAlive[service]
+	b.serviceMu.RUnlock()
+	if !ok {
+		return b.IsAlive()
+	}
+	return alive
 }
 
 // GetWeight returns the weight of the backend for load balancing decisions
@@ -78,7 +154,81 @@ func (b *Backend) RecordMetrics(duration time.Duration, success bool) {
 		value = 1
 	}
 	b.successEWMA = successAlpha*value + (1-successAlpha)*b.successEWMA
+	b.errorWindow = append(b.errorWindow, errorSample{at: time.Now(), success: success})
 	b.mux.Unlock()
+
+	b.metrics.ObserveDuration(b.URL.String(), sample)
+	b.metrics.SetBackendScore(b.URL.String(), b.Score())
+}
+
+// errorRate returns the fraction of errorWindow samples within the last
+// window (as of now) that were failures, along with how many samples fell
+// in that window. Samples older than the window are trimmed in place as a
+// side effect, so the window's memory doesn't grow unbounded.
+func (b *Backend) errorRate(now time.Time, window time.Duration) (rate float64, samples int) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	cutoff := now.Add(-window)
+	kept := b.errorWindow[:0]
+	failures := 0
+	for _, s := range b.errorWindow {
+		if s.at.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, s)
+		if !s.success {
+			failures++
+		}
+	}
+	b.errorWindow = kept
+
+	if len(kept) == 0 {
+		return 0, 0
+	}
+	return float64(failures) / float64(len(kept)), len(kept)
+}
+
+// recordProbeLatency updates probeLatencyEWMA with one active health-probe
+// round-trip duration.
+func (b *Backend) recordProbeLatency(duration time.Duration) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	const alpha = 0.2
+	sample := duration.Seconds()
+	if b.probeLatencyEWMA == 0 {
+		b.probeLatencyEWMA = sample
+	} else {
+		b.probeLatencyEWMA = alpha*sample + (1-alpha)*b.probeLatencyEWMA
+	}
+}
+
+// isEjected reports whether backend is still within its passive-ejection
+// backoff window as of now.
+func (b *Backend) isEjected(now time.Time) bool {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	return !b.ejectedUntil.IsZero() && now.Before(b.ejectedUntil)
+}
+
+// eject marks backend ejected until now+backoff and increments ejectionCount
+// (which never resets, so repeated ejections keep growing the backoff per
+// HealthCheckOptions.EjectionBackoff).
+func (b *Backend) eject(now time.Time, backoff func(count int) time.Duration) int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.ejectionCount++
+	b.ejectedUntil = now.Add(backoff(b.ejectionCount))
+	return b.ejectionCount
+}
+
+// reinstate clears backend's ejection backoff so it's probed normally again.
+// ejectionCount is left untouched so a future re-ejection still doubles from
+// where it left off.
+func (b *Backend) reinstate() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.ejectedUntil = time.Time{}
 }
 
 // ExecuteRequest runs the request through the circuit breaker
@@ -139,42 +289,321 @@ func (b *Backend) Score() float64 {
 	return base * penalty * load * circuitMultiplier
 }
 
+// poolSnapshot is an immutable view of the backend pool: backends, the
+// region index derived from them, and a cumulative-weight array for O(log n)
+// weighted selection. ServerPool holds one behind an atomic.Pointer so
+// readers (GetNextPeer, GetNextPeerWeighted, Backends, ...) never take a
+// lock - they just load the pointer and read through it (Backends still
+// allocates the copy it returns).
+// Mutators build a new poolSnapshot and swap it in; the old one stays valid
+// for any reader still holding it.
+type poolSnapshot struct {
+	backends []*Backend
+	regions  map[string][]*Backend
+	// cumWeights[i] is the sum of backends[0..i].Weight; cumWeights[len-1]
+	// is the pool's total weight. backendAtWeight binary-searches this to
+	// pick a backend for a given cumulative-weight position.
+	cumWeights []int
+}
+
+// newPoolSnapshot builds a poolSnapshot from backends. backends is taken by
+// reference, not copied, so callers must pass a slice they won't mutate
+// further (the append-grow-a-new-backing-array pattern mutators already use
+// for AddBackend/removeBackendLocal/etc. satisfies this).
+func newPoolSnapshot(backends []*Backend) *poolSnapshot {
+	regions := make(map[string][]*Backend)
+	cumWeights := make([]int, len(backends))
+	total := 0
+	for i, b := range backends {
+		total += b.Weight
+		cumWeights[i] = total
+		if b.Region != "" {
+			regions[b.Region] = append(regions[b.Region], b)
+		}
+	}
+	return &poolSnapshot{backends: backends, regions: regions, cumWeights: cumWeights}
+}
+
+func (ps *poolSnapshot) totalWeight() int {
+	if ps == nil || len(ps.cumWeights) == 0 {
+		return 0
+	}
+	return ps.cumWeights[len(ps.cumWeights)-1]
+}
+
+// backendAtWeight returns the backend whose weighted range covers
+// cumulative-weight position n (0 <= n < totalWeight()), found by binary
+// search over cumWeights rather than indexing into a flattened
+// one-entry-per-weight-unit slice.
+func (ps *poolSnapshot) backendAtWeight(n int) *Backend {
+	idx := sort.Search(len(ps.cumWeights), func(i int) bool { return ps.cumWeights[i] > n })
+	if idx >= len(ps.backends) {
+		return nil
+	}
+	return ps.backends[idx]
+}
+
 type ServerPool struct {
-	backends          []*Backend
-	weighted          []*Backend
-	current           uint64
-	stickySessions    map[string]*Backend // Map to store sticky session assignments (fallback)
-	stickyMux         sync.RWMutex        // Mutex for sticky session operations
-	regions           map[string][]*Backend
-	mux               sync.RWMutex
-	sessionManager    *loadbalancer.StickySessionManager
-	autoScalingManager *loadbalancer.AutoScalingStateManager
-	useRedis           bool
-	consulManager     *loadbalancer.ConsulServiceManager
-	useConsul          bool
+	snapshot atomic.Pointer[poolSnapshot]
+	current  uint64
+
+	stickySessions map[string]*Backend // Map to store sticky session assignments (fallback)
+	stickyMux      sync.RWMutex        // Mutex for sticky session operations
+
+	sessionManager      *loadbalancer.StickySessionManager
+	autoScalingManager   *loadbalancer.AutoScalingStateManager
+	useRedis             bool
+	consulManager       *loadbalancer.ConsulServiceManager
+	useConsul            bool
+	k8sManager          *k8s.ServiceManager
+	useK8s               bool
 	trafficPolicyEngine *loadbalancer.TrafficPolicyEngine
+	breakerRegistry     *loadbalancer.BreakerRegistry
+	redisClient         loadbalancer.RedisClient
+	keyPrefix           string
+	leader              *loadbalancer.Leader
+	soleInstanceToken   int64
+	clusterBus          *loadbalancer.ClusterBus
+
+	// provisionedBackends tracks, in provisioning order, the URLs of
+	// backends AddProvisionedBackend added on AutoScaler's behalf, so
+	// LastProvisionedBackend can hand scale-down a removal candidate without
+	// ever touching the statically-configured backends.
+	provisionedBackends []string
+	provisionedMux       sync.Mutex
+
+	// sessionCounts holds this replica's view of how many sticky sessions
+	// each backend holds, keyed by backend URL. Mirrored into Redis (via
+	// incrementSessionCount) when EnableRedisSupport is active, so
+	// DrainController's fair-share target converges across replicas instead
+	// of each one rebalancing against its own local slice of sessions.
+	sessionCounts    map[string]int64
+	sessionCountsMux sync.RWMutex
+
+	// drainingSessions marks session IDs DrainController has evicted, with
+	// the time the mark expires. GetBackendForStickySession consults this to
+	// reject a still-in-flight session instead of re-routing it to the
+	// backend it was just evicted from.
+	drainingSessions map[string]time.Time
+	drainMux         sync.RWMutex
+	drainController  *loadbalancer.DrainController
+
+	// logger records backend state transitions (AddBackend, RemoveBackend,
+	// SetBackendWeight, EvictSession) with structured fields. Defaults to a
+	// no-op logger so ServerPool works without EnableLogger having been
+	// called.
+	logger loadbalancer.Logger
+
+	// healthCheckOpts, once set via EnableHealthChecks, switches HealthCheck
+	// from its original net.DialTimeout check to active HTTP(S) probing plus
+	// passive outlier ejection. Nil keeps the original TCP-dial behavior.
+	healthCheckOpts *loadbalancer.HealthCheckOptions
+	healthHTTPClient *http.Client
+	// healthProbes holds the HealthProbe implementations probeBackend picks
+	// among via Backend.ProbeType, keyed "http"/"tcp"/"grpc". Built once in
+	// EnableHealthChecks since GRPCProbe caches a ClientConn per backend
+	// address and needs to be reused across ticks to benefit from that.
+	healthProbes map[string]loadbalancer.HealthProbe
+
+	// healthCheckManager runs the per-(service, backend) probing goroutines
+	// started by RegisterService. Built lazily on the first RegisterService
+	// call, since most pools never use multi-service health checks.
+	healthCheckManager *loadbalancer.HealthCheckManager
+	// serviceBackends maps a Service name to its member backends, keyed by
+	// backend URL, so healthCheckManager's OnUpdate callback (which only
+	// knows service name + URL string) can find the Backend to update.
+	serviceBackends map[string]map[string]*Backend
+	serviceMux      sync.RWMutex
+
+	// metrics is the Prometheus-backed lb_* metrics surface. Nil (the
+	// default) makes every metrics.* call below a no-op, since LBMetrics's
+	// methods are nil-receiver-safe.
+	metrics *loadbalancer.LBMetrics
+
+	// strategy picks the algorithm SelectBackend uses to choose among a
+	// region's (or the whole pool's) healthy backends. Zero value is
+	// loadbalancer.Weighted, SelectBackend's original behavior.
+	strategy loadbalancer.SelectionStrategy
 }
 
-func (s *ServerPool) AddBackend(backend *Backend) {
-	s.mux.Lock()
-	s.backends = append(s.backends, backend)
-	for i := 0; i < backend.Weight; i++ {
-		s.weighted = append(s.weighted, backend)
+// EnableMetrics attaches metrics so backend state, request outcomes, and
+// sticky-session hits update the lb_* Prometheus collectors. Call
+// AddBackend for every backend after this so each one picks up metrics for
+// its own RecordMetrics calls.
+func (s *ServerPool) EnableMetrics(metrics *loadbalancer.LBMetrics) {
+	s.metrics = metrics
+}
+
+// SetSelectionStrategy picks the algorithm SelectBackend uses among a
+// region's (or the whole pool's) healthy backends. Call it once at
+// construction time, alongside the other Enable*/Set* setters; a
+// TrafficPolicy's own Strategy field (see SelectBackendWithPolicy) overrides
+// this per request when it's set to anything but the default Weighted.
+func (s *ServerPool) SetSelectionStrategy(strategy loadbalancer.SelectionStrategy) {
+	s.strategy = strategy
+}
+
+// EnableHealthChecks switches HealthCheck from a plain TCP dial to active
+// HTTP(S) probing (opts.Path against each backend's own scheme+host,
+// checked against opts.ExpectedStatus/BodyRegex) plus passive outlier
+// ejection fed by RecordMetrics. Pass a zero HealthCheckOptions{} (Path=="")
+// to leave HealthCheck on its original TCP-dial fallback.
+func (s *ServerPool) EnableHealthChecks(opts loadbalancer.HealthCheckOptions) {
+	resolved := opts.WithDefaults()
+	s.healthCheckOpts = &resolved
+	s.healthHTTPClient = &http.Client{Timeout: resolved.Timeout}
+	s.healthProbes = map[string]loadbalancer.HealthProbe{
+		"http": &loadbalancer.HTTPProbe{Client: s.healthHTTPClient},
+		"tcp":  &loadbalancer.TCPProbe{},
+		"grpc": loadbalancer.NewGRPCProbe(),
+	}
+}
+
+// RegisterService starts one probing goroutine per backend in backends,
+// checking cfg independently of every other service - including another
+// RegisterService call sharing one of the same backends. This lets a single
+// Backend belong to several logical Services (e.g. a gRPC service and an
+// HTTP admin endpoint on the same instance) each with its own
+// HealthCheckConfig, without a probe failure on one evicting the backend
+// from the others: query Backend.IsAliveForService(name) rather than
+// IsAlive() for service-scoped routing decisions. Calling RegisterService
+// again with the same name replaces its previous backend list and config.
+func (s *ServerPool) RegisterService(name string, cfg loadbalancer.HealthCheckConfig, backends []*Backend) {
+	if s.healthCheckManager == nil {
+		s.healthCheckManager = loadbalancer.NewHealthCheckManager()
+		s.healthCheckManager.OnUpdate = s.applyServiceHealthUpdate
+	}
+
+	targets := make([]loadbalancer.HealthCheckTarget, 0, len(backends))
+	byURL := make(map[string]*Backend, len(backends))
+	for _, b := range backends {
+		key := b.URL.String()
+		byURL[key] = b
+		targets = append(targets, loadbalancer.HealthCheckTarget{Key: key, URL: b.URL})
+	}
+
+	s.serviceMux.Lock()
+	if s.serviceBackends == nil {
+		s.serviceBackends = make(map[string]map[string]*Backend)
+	}
+	s.serviceBackends[name] = byURL
+	s.serviceMux.Unlock()
+
+	s.healthCheckManager.RegisterService(name, cfg, targets)
+}
+
+// applyServiceHealthUpdate is healthCheckManager's OnUpdate callback: it
+// resolves backendURL back to the Backend RegisterService registered for
+// service and updates its per-service alive bit, logging on a transition.
+func (s *ServerPool) applyServiceHealthUpdate(service, backendURL string, alive bool) {
+	s.serviceMux.RLock()
+	b, ok := s.serviceBackends[service][backendURL]
+	s.serviceMux.RUnlock()
+	if !ok {
+		return
+	}
+
+	wasAlive := b.IsAliveForService(service)
+	b.SetAliveForService(service, alive)
+	if wasAlive == alive {
+		return
+	}
+	if alive {
+		s.log().Info("backend marked up for service",
+			loadbalancer.String("service", service),
+			loadbalancer.String("backend_url", backendURL),
+		)
+	} else {
+		s.log().Warn("backend marked down for service",
+			loadbalancer.String("service", service),
+			loadbalancer.String("backend_url", backendURL),
+		)
+	}
+}
+
+// EnableLogger attaches logger so backend state transitions are recorded
+// with {backend_url, region, weight, circuit_state, active_conns} fields
+// instead of only the plain-text log.Printf lines.
+func (s *ServerPool) EnableLogger(logger loadbalancer.Logger) {
+	s.logger = logger
+}
+
+// log returns s.logger, or a no-op logger if EnableLogger was never called.
+func (s *ServerPool) log() loadbalancer.Logger {
+	if s.logger == nil {
+		return loadbalancer.NewNoopLogger()
+	}
+	return s.logger
+}
+
+// EnableBreakerRegistry makes registry the source of truth for per-backend
+// circuit breaker state. Once enabled, AddBackend points every backend's
+// CircuitBreaker at the registry's Tracker for its URL instead of a
+// standalone one, so SetBackendState and breaker events fanned in from
+// peers via the registry's ClusterBus publishing actually reach Score,
+// ExecuteRequest, IsCircuitBreakerOpen, and the health-check/ejection path -
+// there is exactly one Tracker per backend URL, and the registry owns it.
+func (s *ServerPool) EnableBreakerRegistry(registry *loadbalancer.BreakerRegistry) {
+	s.breakerRegistry = registry
+}
+
+// SetBackendState forces the circuit breaker for backendURL into state. It
+// requires EnableBreakerRegistry to have been called; without a registry
+// there's no single owner of breaker state to mutate safely.
+func (s *ServerPool) SetBackendState(backendURL string, state loadbalancer.CircuitBreakerState) error {
+	if s.breakerRegistry == nil {
+		return fmt.Errorf("breaker registry not enabled")
 	}
-	if s.regions == nil {
-		s.regions = make(map[string][]*Backend)
+	s.breakerRegistry.Get(backendURL).ForceState(state)
+	return nil
+}
+
+// updateSnapshot swaps in a new poolSnapshot built from the current one by
+// build, retrying if a concurrent mutator (AddBackend racing RemoveBackend,
+// say) swapped the pointer first - the same optimistic-concurrency shape as
+// CAS-based counters, just over a whole immutable snapshot instead of an
+// int. build may return its argument unchanged (e.g. "not found") - that's a
+// trivially-successful no-op swap.
+func (s *ServerPool) updateSnapshot(build func(old *poolSnapshot) *poolSnapshot) *poolSnapshot {
+	for {
+		old := s.snapshot.Load()
+		next := build(old)
+		if s.snapshot.CompareAndSwap(old, next) {
+			return next
+		}
 	}
-	if backend.Region != "" {
-		s.regions[backend.Region] = append(s.regions[backend.Region], backend)
+}
+
+func (s *ServerPool) AddBackend(backend *Backend) {
+	backend.metrics = s.metrics
+	if s.breakerRegistry != nil {
+		backend.CircuitBreaker = s.breakerRegistry.Get(backend.URL.String())
 	}
-	s.mux.Unlock()
+	s.updateSnapshot(func(old *poolSnapshot) *poolSnapshot {
+		var backends []*Backend
+		if old != nil {
+			backends = append(backends, old.backends...)
+		}
+		backends = append(backends, backend)
+		return newPoolSnapshot(backends)
+	})
 	log.Printf("[INFO] Added backend: %s (weight: %d)", backend.URL.String(), backend.Weight)
+	s.metrics.SetBackendUp(backend.URL.String(), backend.IsAlive())
+	s.log().Info("backend added",
+		loadbalancer.String("backend_url", backend.URL.String()),
+		loadbalancer.String("region", backend.Region),
+		loadbalancer.Int("weight", backend.Weight),
+		loadbalancer.String("circuit_state", backend.GetCircuitBreakerState().String()),
+		loadbalancer.Int64("active_conns", backend.ActiveConnections()),
+	)
 }
 
 func (s *ServerPool) NextIndex() int {
-	s.mux.RLock()
-	length := len(s.backends)
-	s.mux.RUnlock()
+	snap := s.snapshot.Load()
+	length := 0
+	if snap != nil {
+		length = len(snap.backends)
+	}
 	if length == 0 {
 		return 0
 	}
@@ -183,9 +612,11 @@ func (s *ServerPool) NextIndex() int {
 
 func (s *ServerPool) GetNextPeer() *Backend {
 	next := s.NextIndex()
-	s.mux.RLock()
-	backends := append([]*Backend(nil), s.backends...)
-	s.mux.RUnlock()
+	snap := s.snapshot.Load()
+	if snap == nil {
+		return nil
+	}
+	backends := snap.backends
 	l := len(backends) + next
 	for i := next; i < l; i++ {
 		if len(backends) == 0 {
@@ -203,18 +634,16 @@ func (s *ServerPool) GetNextPeer() *Backend {
 }
 
 func (s *ServerPool) GetNextPeerWeighted() *Backend {
-	s.mux.RLock()
-	weighted := append([]*Backend(nil), s.weighted...)
-	s.mux.RUnlock()
-	if len(weighted) == 0 {
+	snap := s.snapshot.Load()
+	total := snap.totalWeight()
+	if total == 0 {
 		return nil
 	}
-	length := len(weighted)
-	start := int(atomic.AddUint64(&s.current, 1)-1) % length
-	for i := 0; i < length; i++ {
-		idx := (start + i) % length
-		backend := weighted[idx]
-		if backend.IsAlive() {
+	start := int(atomic.AddUint64(&s.current, 1)-1) % total
+	for i := 0; i < total; i++ {
+		idx := (start + i) % total
+		backend := snap.backendAtWeight(idx)
+		if backend != nil && backend.IsAlive() {
 			return backend
 		}
 	}
@@ -229,29 +658,394 @@ func (s *ServerPool) GetStickySession(sessionID string) *Backend {
 
 // EnableRedisSupport enables Redis-based distributed session management
 func (s *ServerPool) EnableRedisSupport(redisClient loadbalancer.RedisClient, keyPrefix string, sessionTTL time.Duration) {
+	s.redisClient = redisClient
+	s.keyPrefix = keyPrefix
 	s.sessionManager = loadbalancer.NewStickySessionManager(redisClient, keyPrefix, sessionTTL)
 	s.autoScalingManager = loadbalancer.NewAutoScalingStateManager(redisClient, keyPrefix, time.Hour)
 	s.useRedis = true
 	log.Printf("[INFO] Redis support enabled for distributed sessions")
 }
 
+// EnableLeaderElection starts this replica campaigning for the auto-scaling
+// leader lease, identifying itself as id (e.g. hostname or pod name). Must be
+// called after EnableRedisSupport. Only the replica that wins the lease will
+// have TryScale actually invoke ShouldScale; every replica can still call
+// AutoScalingState for local decisions regardless of leadership. Mutually
+// exclusive with EnableSoleInstanceAutoScaling - call whichever matches the
+// deployment, not both.
+func (s *ServerPool) EnableLeaderElection(ctx context.Context, id string, leaseTTL, renewEvery time.Duration) {
+	if s.redisClient == nil {
+		log.Printf("[WARN] EnableLeaderElection called before EnableRedisSupport, ignoring")
+		return
+	}
+	s.leader = loadbalancer.NewLeader(s.redisClient, s.keyPrefix, id, leaseTTL, renewEvery)
+	s.leader.Campaign(ctx)
+	log.Printf("[INFO] Leader election enabled for auto-scaling (id=%s)", id)
+}
+
+// EnableSoleInstanceAutoScaling lets TryScale act without a Leader lease, for
+// deployments that run exactly one replica and so have no real election to
+// run. It mints a fencing token from the same counter Leader uses, so a
+// replica later joined by real leader election (or another sole-instance
+// replica started after a misconfiguration) still fences out any stale
+// writes made under this token. Mutually exclusive with EnableLeaderElection.
+func (s *ServerPool) EnableSoleInstanceAutoScaling() {
+	s.soleInstanceToken = loadbalancer.NextFencingToken()
+}
+
+// TryScale asks the AutoScalingStateManager whether to scale based on
+// requestCount. It only mutates replica state once this replica has
+// something backing its fencing token: either it currently holds the leader
+// lease (EnableLeaderElection), or it was told it's the only replica
+// (EnableSoleInstanceAutoScaling). With neither configured there's no safe
+// token to write under, so it returns a no-op decision rather than guessing.
+func (s *ServerPool) TryScale(ctx context.Context, requestCount, threshold int64) (bool, bool, error) {
+	if s.autoScalingManager == nil {
+		return false, false, fmt.Errorf("auto-scaling state manager not enabled")
+	}
+	if s.leader != nil {
+		if !s.leader.IsLeader() {
+			return false, false, nil
+		}
+		return s.autoScalingManager.ShouldScale(ctx, requestCount, threshold, s.leader.Token())
+	}
+	if s.soleInstanceToken == 0 {
+		return false, false, nil
+	}
+	return s.autoScalingManager.ShouldScale(ctx, requestCount, threshold, s.soleInstanceToken)
+}
+
+// AutoScalingState returns the current distributed auto-scaling state for
+// local decisions such as admission control. Any replica may call this
+// regardless of leadership.
+func (s *ServerPool) AutoScalingState(ctx context.Context) (*loadbalancer.AutoScalingState, error) {
+	if s.autoScalingManager == nil {
+		return nil, fmt.Errorf("auto-scaling state manager not enabled")
+	}
+	return s.autoScalingManager.GetAutoScalingState(ctx)
+}
+
 // IsRedisEnabled returns true if Redis support is enabled
 func (s *ServerPool) IsRedisEnabled() bool {
 	return s.useRedis && s.sessionManager != nil
 }
 
-// EnableConsulSupport enables dynamic service discovery via Consul
+// EnableAsyncSessionWrites switches sticky-session writes into async mode:
+// SetSession/updateSession enqueue onto a bounded worker pool instead of
+// blocking the request path on Redis, and a circuit breaker guards both the
+// async and autoscaling-state Redis calls. Must be called after
+// EnableRedisSupport.
+func (s *ServerPool) EnableAsyncSessionWrites(queueSize, workers, highWaterMark int, droppedWrites prometheus.Counter) {
+	if s.sessionManager == nil {
+		log.Printf("[WARN] EnableAsyncSessionWrites called before EnableRedisSupport, ignoring")
+		return
+	}
+	s.sessionManager.EnableAsyncWrites(queueSize, workers, highWaterMark, droppedWrites,
+		loadbalancer.WithInterval(30*time.Second),
+		loadbalancer.WithReadyToTrip(loadbalancer.SlidingWindowReadyToTrip(20, 0.5)),
+	)
+	if s.autoScalingManager != nil {
+		s.autoScalingManager.EnableCircuitBreaker(
+			loadbalancer.WithInterval(30*time.Second),
+			loadbalancer.WithReadyToTrip(loadbalancer.SlidingWindowReadyToTrip(20, 0.5)),
+		)
+	}
+	log.Printf("[INFO] Async sticky-session writes enabled (queue=%d, workers=%d, high_water=%d)", queueSize, workers, highWaterMark)
+}
+
+// EnableClusterBus wires this replica into bus: circuit breaker transitions
+// are published as they happen (via EnableBreakerRegistry's registry), and
+// this replica subscribes to session-invalidate, breaker-state, and
+// config-update so it mirrors decisions made by peers. Call after
+// EnableBreakerRegistry so breaker transitions are published from the start.
+func (s *ServerPool) EnableClusterBus(ctx context.Context, bus *loadbalancer.ClusterBus) {
+	s.clusterBus = bus
+
+	if s.breakerRegistry != nil {
+		s.breakerRegistry.EnableClusterPublish(ctx, bus)
+	}
+
+	go func() {
+		err := bus.SubscribeSessionInvalidate(ctx, func(evt loadbalancer.SessionInvalidateEvent) {
+			if s.sessionManager != nil {
+				s.sessionManager.InvalidateBackend(evt.BackendURL)
+			}
+			s.clearStickySessionsFor(evt.BackendURL)
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[WARN] ClusterBus: session-invalidate subscription ended: %v", err)
+		}
+	}()
+
+	go func() {
+		err := bus.SubscribeBreakerState(ctx, func(evt loadbalancer.BreakerStateEvent) {
+			if err := s.SetBackendState(evt.BackendURL, evt.State); err != nil {
+				log.Printf("[WARN] ClusterBus: failed to apply breaker state from peer for %s: %v", evt.BackendURL, err)
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[WARN] ClusterBus: breaker-state subscription ended: %v", err)
+		}
+	}()
+
+	go func() {
+		err := bus.SubscribeConfigUpdate(ctx, func(evt loadbalancer.ConfigUpdateEvent) {
+			switch evt.Action {
+			case "remove":
+				s.removeBackendLocal(evt.BackendURL)
+			case "weight":
+				s.setBackendWeightLocal(evt.BackendURL, evt.Weight)
+			default:
+				log.Printf("[INFO] ClusterBus: ignoring config update action %q for %s (dynamic backend addition requires operator action)", evt.Action, evt.BackendURL)
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			log.Printf("[WARN] ClusterBus: config-update subscription ended: %v", err)
+		}
+	}()
+
+	log.Printf("[INFO] ClusterBus enabled for cache invalidation, breaker fan-out, and config updates")
+}
+
+// EnableSessionRebalancing starts a DrainController that periodically compares
+// each backend's share of sticky sessions against a totalSessions/healthyBackends
+// target and gradually evicts sessions from backends running over it, so
+// those clients reconnect and land on SelectBackend's current pick (e.g.
+// after a scale-up, or once an unhealthy peer returns and should get its
+// fair share of sessions back). Stops when ctx is canceled.
+func (s *ServerPool) EnableSessionRebalancing(ctx context.Context, opts loadbalancer.RebalanceOptions) {
+	s.drainController = loadbalancer.NewDrainController(s, opts)
+	go s.drainController.Start(ctx)
+	log.Printf("[INFO] Session rebalancing enabled (tolerance=%.0f%%, drain_rate=%.1f/s, interval=%s)",
+		opts.Tolerance*100, opts.DrainRate, opts.Interval)
+}
+
+// RemoveBackend drains backendURL from the pool: it's removed from routing
+// and any sticky sessions pointing at it are cleared. If EnableClusterBus was
+// called, peers are notified to do the same and to evict it from their local
+// session cache. Returns false if backendURL wasn't in the pool.
+func (s *ServerPool) RemoveBackend(ctx context.Context, backendURL string) bool {
+	removed := s.removeBackendLocal(backendURL)
+	if removed && s.clusterBus != nil {
+		if err := s.clusterBus.PublishSessionInvalidate(ctx, backendURL); err != nil {
+			log.Printf("[WARN] ClusterBus: failed to publish session invalidation for %s: %v", backendURL, err)
+		}
+		if err := s.clusterBus.PublishConfigUpdate(ctx, loadbalancer.ConfigUpdateEvent{Action: "remove", BackendURL: backendURL}); err != nil {
+			log.Printf("[WARN] ClusterBus: failed to publish backend removal for %s: %v", backendURL, err)
+		}
+	}
+	return removed
+}
+
+// removeBackendLocal applies a backend removal to this replica only; it's
+// the shared path for both a local RemoveBackend call and an incoming
+// ConfigUpdateEvent from a peer.
+func (s *ServerPool) removeBackendLocal(backendURL string) bool {
+	found := false
+	s.updateSnapshot(func(old *poolSnapshot) *poolSnapshot {
+		found = false
+		if old == nil {
+			return old
+		}
+		backends := make([]*Backend, 0, len(old.backends))
+		for _, b := range old.backends {
+			if b.URL.String() == backendURL {
+				found = true
+				continue
+			}
+			backends = append(backends, b)
+		}
+		if !found {
+			return old
+		}
+		return newPoolSnapshot(backends)
+	})
+
+	if !found {
+		return false
+	}
+
+	s.clearStickySessionsFor(backendURL)
+	if s.sessionManager != nil {
+		s.sessionManager.InvalidateBackend(backendURL)
+	}
+
+	log.Printf("[INFO] Removed backend: %s", backendURL)
+	s.log().Info("backend removed", loadbalancer.String("backend_url", backendURL))
+	return true
+}
+
+// clearStickySessionsFor drops every fallback sticky-session entry pointing
+// at backendURL and resets its session count, since a removed backend no
+// longer participates in DrainController's fair-share target.
+func (s *ServerPool) clearStickySessionsFor(backendURL string) {
+	s.stickyMux.Lock()
+	for sessionID, backend := range s.stickySessions {
+		if backend.URL.String() == backendURL {
+			delete(s.stickySessions, sessionID)
+		}
+	}
+	s.stickyMux.Unlock()
+
+	s.sessionCountsMux.Lock()
+	delete(s.sessionCounts, backendURL)
+	s.sessionCountsMux.Unlock()
+
+	if s.IsRedisEnabled() {
+		if err := s.redisClient.Del(context.Background(), s.sessionCountKey(backendURL)); err != nil {
+			log.Printf("[WARN] Failed to clear Redis session count for %s: %v", backendURL, err)
+		}
+	}
+}
+
+// SetBackendWeight updates backendURL's routing weight. If EnableClusterBus
+// was called, peers are notified to apply the same change. Returns false if
+// backendURL isn't in the pool.
+func (s *ServerPool) SetBackendWeight(ctx context.Context, backendURL string, weight int) bool {
+	changed := s.setBackendWeightLocal(backendURL, weight)
+	if changed && s.clusterBus != nil {
+		evt := loadbalancer.ConfigUpdateEvent{Action: "weight", BackendURL: backendURL, Weight: weight}
+		if err := s.clusterBus.PublishConfigUpdate(ctx, evt); err != nil {
+			log.Printf("[WARN] ClusterBus: failed to publish weight update for %s: %v", backendURL, err)
+		}
+	}
+	return changed
+}
+
+// DrainBackend stops backendURL from taking new sticky sessions (by setting
+// its weight to 0, which also drops it from weighted round-robin; Score()
+// and P2C sampling treat a weight below 1 as 1, so least-loaded and P2C
+// selection can still pick it) and evicts every sticky session currently assigned to it on this
+// replica. Returns how many sessions were evicted; found is false if
+// backendURL isn't in the pool. It's the operation behind the admin
+// control-plane's DrainBackend RPC.
+func (s *ServerPool) DrainBackend(backendURL string) (evicted int, found bool) {
+	if !s.setBackendWeightLocal(backendURL, 0) {
+		return 0, false
+	}
+	sessions := s.EvictSession(backendURL, math.MaxInt)
+	return len(sessions), true
+}
+
+// TotalActiveConnections sums ActiveConnections() across every backend in
+// the pool - used by server.Multiplexer's graceful shutdown to know when
+// in-flight requests have drained.
+func (s *ServerPool) TotalActiveConnections() int64 {
+	var total int64
+	for _, b := range s.Backends() {
+		total += b.ActiveConnections()
+	}
+	return total
+}
+
+// setBackendWeightLocal applies a weight change to this replica only; it's
+// the shared path for both a local SetBackendWeight call and an incoming
+// ConfigUpdateEvent from a peer.
+func (s *ServerPool) setBackendWeightLocal(backendURL string, weight int) bool {
+	found := false
+	s.updateSnapshot(func(old *poolSnapshot) *poolSnapshot {
+		found = false
+		if old == nil {
+			return old
+		}
+		var target *Backend
+		for _, b := range old.backends {
+			if b.URL.String() == backendURL {
+				target = b
+				break
+			}
+		}
+		if target == nil {
+			return old
+		}
+		found = true
+		target.Weight = weight
+		return newPoolSnapshot(old.backends)
+	})
+	if !found {
+		return false
+	}
+
+	log.Printf("[INFO] Updated backend weight: %s -> %d", backendURL, weight)
+	s.log().Info("backend weight changed",
+		loadbalancer.String("backend_url", backendURL),
+		loadbalancer.Int("weight", weight),
+	)
+	return true
+}
+
+// ReplaceBackends atomically swaps the entire backend pool, the same way
+// UpdateBackendsFromConsul does for Consul. Prefer AddBackend/RemoveBackend/
+// UpdateBackend when only some backends changed: those leave every
+// untouched Backend's CircuitBreaker/health/EWMA state alone, where
+// ReplaceBackends discards and rebuilds every Backend in the pool.
+func (s *ServerPool) ReplaceBackends(backends []*Backend) {
+	s.snapshot.Store(newPoolSnapshot(backends))
+	log.Printf("[INFO] Replaced backend pool: %d backends", len(backends))
+}
+
+// UpdateBackend updates backendURL's Weight and Region in place, leaving
+// its CircuitBreaker, health-check, and EWMA score state untouched - unlike
+// ReplaceBackends, which discards and recreates every Backend. Returns
+// false if backendURL isn't in the pool.
+func (s *ServerPool) UpdateBackend(backendURL string, weight int, region string) bool {
+	found := false
+	s.updateSnapshot(func(old *poolSnapshot) *poolSnapshot {
+		found = false
+		if old == nil {
+			return old
+		}
+		var target *Backend
+		for _, b := range old.backends {
+			if b.URL.String() == backendURL {
+				target = b
+				break
+			}
+		}
+		if target == nil {
+			return old
+		}
+		found = true
+		target.Weight = weight
+		target.Region = region
+		return newPoolSnapshot(old.backends)
+	})
+	if !found {
+		return false
+	}
+
+	log.Printf("[INFO] Updated backend: %s (weight=%d, region=%s)", backendURL, weight, region)
+	s.log().Info("backend updated",
+		loadbalancer.String("backend_url", backendURL),
+		loadbalancer.Int("weight", weight),
+		loadbalancer.String("region", region),
+	)
+	return true
+}
+
+// EnableConsulSupport enables dynamic service discovery via Consul. Pool
+// membership tracks Consul automatically from here on: every change
+// StartWatch (or its polling equivalent) observes is fed straight into
+// UpdateBackendsFromConsul via NotifyOnChange, so backends come and go with
+// service registration, deregistration, and health check transitions.
 func (s *ServerPool) EnableConsulSupport(consulManager *loadbalancer.ConsulServiceManager) {
 	s.consulManager = consulManager
 	s.useConsul = true
-	
+
+	changed := consulManager.NotifyOnChange()
+	go func() {
+		for range changed {
+			s.UpdateBackendsFromConsul()
+		}
+	}()
+
 	// Start Consul service discovery
 	go func() {
 		if err := s.consulManager.StartWatch(context.Background()); err != nil {
 			log.Printf("[ERROR] Failed to start Consul watch: %v", err)
 		}
 	}()
-	
+
 	log.Printf("[INFO] Consul service discovery enabled")
 }
 
@@ -260,6 +1054,192 @@ func (s *ServerPool) IsConsulEnabled() bool {
 	return s.useConsul && s.consulManager != nil
 }
 
+// EnableKubernetesSupport enables dynamic service discovery via a
+// Kubernetes Service's EndpointSlices, mirroring EnableConsulSupport: pool
+// membership tracks manager automatically from here on, fed into
+// UpdateBackendsFromKubernetes via NotifyOnChange every time the informer
+// reconciles.
+func (s *ServerPool) EnableKubernetesSupport(manager *k8s.ServiceManager) {
+	s.k8sManager = manager
+	s.useK8s = true
+
+	changed := manager.NotifyOnChange()
+	go func() {
+		for range changed {
+			s.UpdateBackendsFromKubernetes()
+		}
+	}()
+
+	go func() {
+		if err := s.k8sManager.StartWatch(context.Background()); err != nil {
+			log.Printf("[ERROR] Failed to start Kubernetes watch: %v", err)
+		}
+	}()
+
+	log.Printf("[INFO] Kubernetes service discovery enabled")
+}
+
+// IsKubernetesEnabled returns true if Kubernetes service discovery is enabled.
+func (s *ServerPool) IsKubernetesEnabled() bool {
+	return s.useK8s && s.k8sManager != nil
+}
+
+// UpdateBackendsFromKubernetes replaces the backend pool with manager's
+// currently discovered, ready EndpointSlice addresses, the same wholesale
+// ReplaceBackends approach UpdateBackendsFromConsul uses for Consul.
+// Backend.Region comes straight from each Service's Region (itself read off
+// topology.kubernetes.io/region), so geo-aware routing picks these
+// backends up automatically.
+func (s *ServerPool) UpdateBackendsFromKubernetes() {
+	if !s.IsKubernetesEnabled() {
+		return
+	}
+
+	services := s.k8sManager.GetAllServices()
+
+	var backends []*Backend
+	for _, service := range services {
+		serviceURL, err := url.Parse(fmt.Sprintf("http://%s:%d", service.Address, service.Port))
+		if err != nil {
+			log.Printf("[ERROR] Failed to parse Kubernetes endpoint URL %s:%d: %v", service.Address, service.Port, err)
+			continue
+		}
+
+		proxy := httputil.NewSingleHostReverseProxy(serviceURL)
+		if tr, err := loadbalancer.NewMTLSTransportFromEnv(); err != nil {
+			log.Printf("[ERROR] mTLS transport setup failed for Kubernetes endpoint %s: %v", serviceURL, err)
+		} else if tr != nil {
+			proxy.Transport = tr
+			log.Printf("[INFO] mTLS enabled for Kubernetes endpoint %s", serviceURL)
+		}
+
+		backend := &Backend{
+			URL:          serviceURL,
+			ReverseProxy: proxy,
+			Weight:       service.Weight,
+			Region:       service.Region,
+			CircuitBreaker: loadbalancer.NewCircuitBreaker(
+				fmt.Sprintf("k8s-%s", serviceURL.Host),
+				loadbalancer.WithMaxRequests(3),
+				loadbalancer.WithInterval(10*time.Second),
+				loadbalancer.WithTimeout(30*time.Second),
+				loadbalancer.WithReadyToTrip(func(counts loadbalancer.Counts) bool {
+					return counts.ConsecutiveFailures >= 5
+				}),
+				loadbalancer.WithOnStateChange(func(name string, from, to loadbalancer.CircuitBreakerState) {
+					log.Printf("[INFO] Kubernetes backend circuit breaker '%s' changed from %v to %v", name, from, to)
+				}),
+			),
+		}
+		backend.SetAlive(true)
+
+		backends = append(backends, backend)
+	}
+
+	s.ReplaceBackends(backends)
+	log.Printf("[INFO] Updated %d backends from Kubernetes discovery", len(backends))
+}
+
+// EnableDynamicProviders starts every provider in providers through agg and
+// applies each debounced update by diffing it against the current pool: new
+// backend URLs are added via AddBackend, vanished ones removed via
+// RemoveBackend, and changed weight/region applied in place via
+// UpdateBackend - so a backend untouched by the change keeps its
+// CircuitBreaker/health/EWMA state instead of being torn down and rebuilt
+// the way ReplaceBackends (and UpdateBackendsFromConsul) do.
+func (s *ServerPool) EnableDynamicProviders(ctx context.Context, agg *provider.Aggregator, providers map[string]provider.Provider) error {
+	updates, err := agg.Run(ctx, providers)
+	if err != nil {
+		return fmt.Errorf("failed to start dynamic providers: %w", err)
+	}
+
+	go func() {
+		for msgs := range updates {
+			s.applyProviderMessages(msgs)
+		}
+	}()
+
+	log.Printf("[INFO] Dynamic configuration providers enabled: %d provider(s)", len(providers))
+	return nil
+}
+
+// applyProviderMessages reconciles the pool against msgs's merged desired
+// state: add, remove, and in-place update, as described on
+// EnableDynamicProviders.
+func (s *ServerPool) applyProviderMessages(msgs map[string]provider.Message) {
+	desired := provider.MergeBackends(msgs)
+
+	existing := make(map[string]*Backend)
+	for _, b := range s.Backends() {
+		existing[b.URL.String()] = b
+	}
+
+	for urlStr, spec := range desired {
+		if b, ok := existing[urlStr]; ok {
+			if b.Weight != spec.Weight || b.Region != spec.Region {
+				s.UpdateBackend(urlStr, spec.Weight, spec.Region)
+			}
+			continue
+		}
+		backend, err := newBackendFromSpec(spec)
+		if err != nil {
+			log.Printf("[ERROR] dynamic provider: %v", err)
+			continue
+		}
+		s.AddBackend(backend)
+	}
+
+	for urlStr := range existing {
+		if _, ok := desired[urlStr]; !ok {
+			s.RemoveBackend(context.Background(), urlStr)
+		}
+	}
+}
+
+// newBackendFromSpec builds a *Backend for spec the same way
+// UpdateBackendsFromConsul and AddProvisionedBackend do: a reverse proxy to
+// spec.URL, an optional mTLS transport, and a fresh CircuitBreaker.
+func newBackendFromSpec(spec provider.BackendSpec) (*Backend, error) {
+	u, err := url.Parse(spec.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse backend URL %s: %w", spec.URL, err)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	if tr, err := loadbalancer.NewMTLSTransportFromEnv(); err != nil {
+		log.Printf("[ERROR] mTLS transport setup failed for dynamic backend %s: %v", spec.URL, err)
+	} else if tr != nil {
+		proxy.Transport = tr
+		log.Printf("[INFO] mTLS enabled for dynamic backend %s", spec.URL)
+	}
+
+	weight := spec.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+
+	backend := &Backend{
+		URL:          u,
+		ReverseProxy: proxy,
+		Weight:       weight,
+		Region:       spec.Region,
+		CircuitBreaker: loadbalancer.NewCircuitBreaker(
+			fmt.Sprintf("dynamic-%s", u.Host),
+			loadbalancer.WithMaxRequests(3),
+			loadbalancer.WithInterval(10*time.Second),
+			loadbalancer.WithTimeout(30*time.Second),
+			loadbalancer.WithReadyToTrip(func(counts loadbalancer.Counts) bool {
+				return counts.ConsecutiveFailures >= 5
+			}),
+			loadbalancer.WithOnStateChange(func(name string, from, to loadbalancer.CircuitBreakerState) {
+				log.Printf("[INFO] Dynamic backend circuit breaker '%s' changed from %v to %v", name, from, to)
+			}),
+		),
+	}
+	backend.SetAlive(true)
+	return backend, nil
+}
+
 // UpdateBackendsFromConsul updates backends based on Consul discovery
 func (s *ServerPool) UpdateBackendsFromConsul() {
 	if !s.IsConsulEnabled() {
@@ -267,14 +1247,8 @@ func (s *ServerPool) UpdateBackendsFromConsul() {
 	}
 	
 	services := s.consulManager.GetAllServices()
-	s.mux.Lock()
-	defer s.mux.Unlock()
-	
-	// Clear existing backends and reinitialize
-	s.backends = nil
-	s.weighted = nil
-	s.regions = make(map[string][]*Backend)
-	
+
+	var backends []*Backend
 	for _, service := range services {
 		// Create backend URL from Consul service data
 		serviceURL, err := url.Parse(fmt.Sprintf("http://%s:%d", service.Address, service.Port))
@@ -294,25 +1268,103 @@ func (s *ServerPool) UpdateBackendsFromConsul() {
 		
 		backend := &Backend{
 			URL:          serviceURL,
-			Alive:        true,
 			ReverseProxy: proxy,
 			Weight:       service.Weight,
 			Region:       service.Region,
 			CircuitBreaker: s.createCircuitBreakerForService(service),
 		}
-		
-		s.backends = append(s.backends, backend)
-		
-		// Add to weighted routing
-		for i := 0; i < service.Weight; i++ {
-			s.weighted = append(s.weighted, backend)
+		backend.SetAlive(true)
+
+		backends = append(backends, backend)
+	}
+
+	s.snapshot.Store(newPoolSnapshot(backends))
+	log.Printf("[INFO] Updated %d backends from Consul discovery", len(backends))
+}
+
+// BackendCount returns how many backends are currently in the pool.
+func (s *ServerPool) BackendCount() int {
+	snap := s.snapshot.Load()
+	if snap == nil {
+		return 0
+	}
+	return len(snap.backends)
+}
+
+// AddProvisionedBackend registers a backend AutoScaler just provisioned at
+// backendURL, wiring it up the same way the static startup backends and
+// Consul-discovered backends are (reverse proxy, optional mTLS transport,
+// circuit breaker). It carries no region, so region-aware routing skips it
+// but weighted/round-robin selection still reaches it.
+func (s *ServerPool) AddProvisionedBackend(backendURL string) error {
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse provisioned backend URL %s: %w", backendURL, err)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	if tr, err := loadbalancer.NewMTLSTransportFromEnv(); err != nil {
+		log.Printf("[ERROR] mTLS transport setup failed for provisioned backend %s: %v", backendURL, err)
+	} else if tr != nil {
+		proxy.Transport = tr
+		log.Printf("[INFO] mTLS enabled for provisioned backend %s", backendURL)
+	}
+
+	backend := &Backend{
+		URL:          u,
+		ReverseProxy: proxy,
+		Weight:       1,
+		CircuitBreaker: loadbalancer.NewCircuitBreaker(
+			fmt.Sprintf("autoscaled-%s", u.Port()),
+			loadbalancer.WithMaxRequests(3),
+			loadbalancer.WithInterval(10*time.Second),
+			loadbalancer.WithTimeout(30*time.Second),
+			loadbalancer.WithReadyToTrip(func(counts loadbalancer.Counts) bool {
+				return counts.ConsecutiveFailures >= 5
+			}),
+			loadbalancer.WithOnStateChange(func(name string, from, to loadbalancer.CircuitBreakerState) {
+				log.Printf("[INFO] Autoscaled backend circuit breaker '%s' changed from %v to %v", name, from, to)
+			}),
+		),
+	}
+	backend.SetAlive(true)
+
+	s.AddBackend(backend)
+
+	s.provisionedMux.Lock()
+	s.provisionedBackends = append(s.provisionedBackends, backendURL)
+	s.provisionedMux.Unlock()
+
+	return nil
+}
+
+// RemoveProvisionedBackend drains and removes a previously
+// autoscaler-provisioned backend, the same way RemoveBackend does for any
+// other backend. Returns false if it wasn't found.
+func (s *ServerPool) RemoveProvisionedBackend(ctx context.Context, backendURL string) bool {
+	removed := s.RemoveBackend(ctx, backendURL)
+	if removed {
+		s.provisionedMux.Lock()
+		for i, url := range s.provisionedBackends {
+			if url == backendURL {
+				s.provisionedBackends = append(s.provisionedBackends[:i], s.provisionedBackends[i+1:]...)
+				break
+			}
 		}
-		
-		// Add to region mapping
-		s.regions[service.Region] = append(s.regions[service.Region], backend)
+		s.provisionedMux.Unlock()
 	}
-	
-	log.Printf("[INFO] Updated %d backends from Consul discovery", len(s.backends))
+	return removed
+}
+
+// LastProvisionedBackend returns the most recently autoscaler-provisioned
+// backend still in the pool, for scale-down to pick a removal candidate.
+func (s *ServerPool) LastProvisionedBackend() (string, bool) {
+	s.provisionedMux.Lock()
+	defer s.provisionedMux.Unlock()
+	if len(s.provisionedBackends) == 0 {
+		return "", false
+	}
+	return s.provisionedBackends[len(s.provisionedBackends)-1], true
 }
 
 // createCircuitBreakerForService creates a circuit breaker for a Consul service
@@ -331,12 +1383,16 @@ func (s *ServerPool) createCircuitBreakerForService(service loadbalancer.ConsulS
 	)
 }
 
-// EnableTrafficPolicies enables dynamic traffic routing policies
-func (s *ServerPool) EnableTrafficPolicies(policies []loadbalancer.TrafficPolicy) {
-	s.trafficPolicyEngine = loadbalancer.NewTrafficPolicyEngine(s.createBackendMap())
-	
+// EnableTrafficPolicies enables dynamic traffic routing policies. opts
+// configures the underlying TrafficPolicyEngine, e.g.
+// loadbalancer.WithOSSMode(false) to allow partition-scoped policies.
+func (s *ServerPool) EnableTrafficPolicies(policies []loadbalancer.TrafficPolicy, opts ...loadbalancer.TrafficPolicyEngineOption) {
+	s.trafficPolicyEngine = loadbalancer.NewTrafficPolicyEngine(s.createBackendMap(), opts...)
+
 	for _, policy := range policies {
-		s.trafficPolicyEngine.AddPolicy(policy)
+		if err := s.trafficPolicyEngine.AddPolicy(policy); err != nil {
+			log.Printf("[ERROR] Failed to add traffic policy '%s': %v", policy.Name, err)
+		}
 	}
 	
 	log.Printf("[INFO] Traffic policies engine enabled with %d policies", len(policies))
@@ -345,13 +1401,15 @@ func (s *ServerPool) EnableTrafficPolicies(policies []loadbalancer.TrafficPolicy
 // createBackendMap creates a backendMap for traffic policy engine
 func (s *ServerPool) createBackendMap() map[string]interface{} {
 	backendMap := make(map[string]interface{})
-	s.mux.RLock()
-	defer s.mux.RUnlock()
-	
-	for _, backend := range s.backends {
+	snap := s.snapshot.Load()
+	if snap == nil {
+		return backendMap
+	}
+
+	for _, backend := range snap.backends {
 		backendMap[backend.URL.String()] = backend
 	}
-	
+
 	return backendMap
 }
 
@@ -360,30 +1418,36 @@ func (s *ServerPool) IsTrafficPoliciesEnabled() bool {
 	return s.trafficPolicyEngine != nil
 }
 
-// SelectBackendWithPolicy routes request using traffic policies
-func (s *ServerPool) SelectBackendWithPolicy(r *http.Request) *Backend {
+// SelectBackendWithPolicy routes request using traffic policies, also
+// returning the name of whichever policy matched (for the lb_requests_total
+// "policy" label), or "" when no policy applied and GetNextPeerWeighted's
+// fallback was used instead.
+func (s *ServerPool) SelectBackendWithPolicy(r *http.Request) (*Backend, string) {
 	if !s.IsTrafficPoliciesEnabled() {
 		// Fallback to normal selection if no policies
-		return s.GetNextPeerWeighted()
+		return s.GetNextPeerWeighted(), ""
 	}
-	
-    selected, err := s.trafficPolicyEngine.EvaluateRequest(r)
+
+    selected, policyName, err := s.trafficPolicyEngine.EvaluateRequest(r)
 	if err != nil {
 		log.Printf("[WARN] Traffic policy evaluation failed: %v", err)
 		// Fallback to normal selection
-		return s.GetNextPeerWeighted()
+		return s.GetNextPeerWeighted(), ""
 	}
-	
+
     if selected == nil {
 		log.Printf("[WARN] No backend selected by traffic policies, using fallback")
-		return s.GetNextPeerWeighted()
+		if policy, err := s.trafficPolicyEngine.GetPolicyByName(policyName); err == nil && policy.Strategy != loadbalancer.Weighted {
+			return s.selectBackendWithStrategy(getClientRegion(r), policy.Strategy), policyName
+		}
+		return s.GetNextPeerWeighted(), ""
 	}
-	
+
     if b, ok := selected.(*Backend); ok {
-        return b
+        return b, policyName
     }
     // If type assertion fails, fallback
-    return s.GetNextPeerWeighted()
+    return s.GetNextPeerWeighted(), ""
 }
 
 func (s *ServerPool) SetStickySession(sessionID string, backend *Backend) {
@@ -403,9 +1467,12 @@ func (s *ServerPool) GetBackendForStickySession(sessionID string, region string)
 			log.Printf("[WARN] Failed to get session from Redis: %v", err)
 		} else if sessionData != nil {
 			// Find backend by URL from Redis session data
-			for _, backend := range s.backends {
-				if backend.URL.String() == sessionData.BackendURL && backend.IsAlive() {
-					return backend
+			if snap := s.snapshot.Load(); snap != nil {
+				for _, backend := range snap.backends {
+					if backend.URL.String() == sessionData.BackendURL && backend.IsAlive() {
+						s.metrics.RecordStickySessionHit("redis")
+						return backend
+					}
 				}
 			}
 		}
@@ -424,6 +1491,7 @@ func (s *ServerPool) GetBackendForStickySession(sessionID string, region string)
 				log.Printf("[WARN] Failed to store session in Redis: %v", err)
 			}
 		}
+		s.metrics.RecordStickySessionHit("local")
 		return backend
 	}
 
@@ -442,53 +1510,423 @@ func (s *ServerPool) GetBackendForStickySession(sessionID string, region string)
 				log.Printf("[WARN] Failed to store session in Redis: %v", err)
 			}
 		}
+
+		s.incrementSessionCount(backend.URL.String(), 1)
 	}
 
 	return backend
 }
 
+// IsSessionDraining reports whether sessionID was evicted by a
+// DrainController rebalance and should be rejected (with a rebalance signal
+// telling the client to reconnect) instead of routed. The mark is consumed
+// on read and also expires after sessionDrainGracePeriod, so a stale or
+// already-handled eviction can't permanently reject a reused session ID.
+func (s *ServerPool) IsSessionDraining(sessionID string) bool {
+	s.drainMux.Lock()
+	defer s.drainMux.Unlock()
+	expiry, ok := s.drainingSessions[sessionID]
+	if !ok {
+		return false
+	}
+	delete(s.drainingSessions, sessionID)
+	return time.Now().Before(expiry)
+}
+
+// sessionDrainGracePeriod bounds how long an EvictSession mark stays in
+// drainingSessions waiting for the evicted client to reconnect.
+const sessionDrainGracePeriod = 30 * time.Second
+
+// HealthyBackendURLs returns the URLs of every currently alive backend, for
+// DrainController's fair-share target.
+func (s *ServerPool) HealthyBackendURLs() []string {
+	var urls []string
+	for _, b := range s.Backends() {
+		if b.IsAlive() {
+			urls = append(urls, b.URL.String())
+		}
+	}
+	return urls
+}
+
+// sessionCountKey is the Redis key backendURL's sticky-session count is kept
+// under, mirrored via incrementSessionCount so every replica converges on
+// the same count.
+func (s *ServerPool) sessionCountKey(backendURL string) string {
+	return fmt.Sprintf("%s:sessions:count:%s", s.keyPrefix, backendURL)
+}
+
+// incrementSessionCount adjusts backendURL's local sticky-session count by
+// delta (negative to decrement) and, when Redis support is enabled, mirrors
+// the adjustment into Redis so DrainController's target converges across
+// replicas.
+func (s *ServerPool) incrementSessionCount(backendURL string, delta int64) {
+	s.sessionCountsMux.Lock()
+	if s.sessionCounts == nil {
+		s.sessionCounts = make(map[string]int64)
+	}
+	s.sessionCounts[backendURL] += delta
+	s.sessionCountsMux.Unlock()
+
+	if s.IsRedisEnabled() {
+		if _, err := s.redisClient.IncrBy(context.Background(), s.sessionCountKey(backendURL), delta); err != nil {
+			log.Printf("[WARN] Failed to update Redis session count for %s: %v", backendURL, err)
+		}
+	}
+}
+
+// localSessionCount returns this replica's local view of backendURL's
+// sticky-session count, used as a fallback when Redis is unavailable.
+func (s *ServerPool) localSessionCount(backendURL string) int64 {
+	s.sessionCountsMux.RLock()
+	defer s.sessionCountsMux.RUnlock()
+	return s.sessionCounts[backendURL]
+}
+
+// SessionCountsByBackend returns how many sticky sessions each backend
+// currently holds, preferring the Redis-mirrored count (consistent across
+// replicas) and falling back to this replica's local count if Redis is
+// unavailable or not enabled.
+func (s *ServerPool) SessionCountsByBackend() map[string]int64 {
+	backends := s.Backends()
+	counts := make(map[string]int64, len(backends))
+
+	for _, b := range backends {
+		url := b.URL.String()
+		if s.IsRedisEnabled() {
+			raw, err := s.redisClient.Get(context.Background(), s.sessionCountKey(url))
+			if err != nil {
+				log.Printf("[WARN] Failed to read Redis session count for %s, falling back to local: %v", url, err)
+			} else if n, parseErr := strconv.ParseInt(raw, 10, 64); parseErr == nil {
+				counts[url] = n
+				continue
+			}
+		}
+		counts[url] = s.localSessionCount(url)
+	}
+	return counts
+}
+
+// EvictSession picks up to count sticky sessions assigned to backendURL,
+// removes them from the local fallback map and Redis (so
+// GetBackendForStickySession no longer finds them), marks them draining, and
+// decrements backendURL's session count. Returns the evicted session IDs.
+func (s *ServerPool) EvictSession(backendURL string, count int) []string {
+	seen := make(map[string]bool, count)
+	var evicted []string
+
+	s.stickyMux.Lock()
+	for sessionID, backend := range s.stickySessions {
+		if len(evicted) >= count {
+			break
+		}
+		if backend.URL.String() == backendURL {
+			evicted = append(evicted, sessionID)
+			seen[sessionID] = true
+			delete(s.stickySessions, sessionID)
+		}
+	}
+	s.stickyMux.Unlock()
+
+	// On a replica that mostly serves sticky sessions found in Redis rather
+	// than assigned locally, stickySessions rarely has enough candidates
+	// even though SessionCountsByBackend (which prefers the Redis-mirrored
+	// count) correctly sees backendURL as overloaded. sessionManager's
+	// localCache is populated by every Redis-path session read, so it's
+	// this replica's actual view of sessions it has served - fall back to
+	// it when the local map comes up short.
+	if len(evicted) < count && s.sessionManager != nil {
+		for _, sessionID := range s.sessionManager.SessionIDsForBackend(backendURL) {
+			if len(evicted) >= count {
+				break
+			}
+			if seen[sessionID] {
+				continue
+			}
+			evicted = append(evicted, sessionID)
+			seen[sessionID] = true
+		}
+	}
+
+	if len(evicted) == 0 {
+		return evicted
+	}
+
+	s.drainMux.Lock()
+	if s.drainingSessions == nil {
+		s.drainingSessions = make(map[string]time.Time)
+	}
+	expiry := time.Now().Add(sessionDrainGracePeriod)
+	for _, sessionID := range evicted {
+		s.drainingSessions[sessionID] = expiry
+	}
+	s.drainMux.Unlock()
+
+	if s.sessionManager != nil {
+		ctx := context.Background()
+		for _, sessionID := range evicted {
+			if err := s.sessionManager.DeleteSession(ctx, sessionID); err != nil {
+				log.Printf("[WARN] Failed to delete draining session %s from Redis: %v", sessionID, err)
+			}
+		}
+	}
+
+	s.incrementSessionCount(backendURL, -int64(len(evicted)))
+	s.log().Info("sticky sessions evicted",
+		loadbalancer.String("backend_url", backendURL),
+		loadbalancer.Int("count", len(evicted)),
+	)
+	return evicted
+}
+
 // GetTotalWeight calculates the total weight of all healthy backends
 func (s *ServerPool) GetTotalWeight() int {
 	total := 0
-	s.mux.RLock()
-	for _, b := range s.backends {
+	snap := s.snapshot.Load()
+	if snap == nil {
+		return 0
+	}
+	for _, b := range snap.backends {
 		if b.IsAlive() {
 			total += b.GetWeight()
 		}
 	}
-	s.mux.RUnlock()
 	return total
 }
 
 func (s *ServerPool) HealthCheck() {
-	s.mux.RLock()
-	backends := append([]*Backend(nil), s.backends...)
-	s.mux.RUnlock()
+	snap := s.snapshot.Load()
+	var backends []*Backend
+	if snap != nil {
+		backends = snap.backends
+	}
+	if s.healthCheckOpts != nil && s.healthCheckOpts.Path != "" {
+		for _, b := range backends {
+			s.httpHealthCheck(b)
+		}
+	} else {
+		for _, b := range backends {
+			wasAlive := b.IsAlive()
+			conn, err := net.DialTimeout("tcp", b.URL.Host, 2*time.Second)
+			if err != nil {
+				b.SetAlive(false)
+				log.Printf("[WARN] Backend %s is DOWN: %v", b.URL.String(), err)
+				if wasAlive {
+					s.log().Warn("backend marked down",
+						loadbalancer.String("backend_url", b.URL.String()),
+						loadbalancer.String("region", b.Region),
+						loadbalancer.Int("weight", b.GetWeight()),
+						loadbalancer.String("circuit_state", b.GetCircuitBreakerState().String()),
+						loadbalancer.Int64("active_conns", b.ActiveConnections()),
+						loadbalancer.Err(err),
+					)
+				}
+			} else {
+				b.SetAlive(true)
+				conn.Close()
+				if !wasAlive {
+					s.log().Info("backend marked up",
+						loadbalancer.String("backend_url", b.URL.String()),
+						loadbalancer.String("region", b.Region),
+						loadbalancer.Int("weight", b.GetWeight()),
+					)
+				}
+			}
+		}
+	}
+
 	for _, b := range backends {
-		conn, err := net.DialTimeout("tcp", b.URL.Host, 2*time.Second)
-		if err != nil {
-			b.SetAlive(false)
-			log.Printf("[WARN] Backend %s is DOWN: %v", b.URL.String(), err)
-		} else {
-			b.SetAlive(true)
-			conn.Close()
+		s.metrics.SetBackendUp(b.URL.String(), b.IsAlive())
+		s.metrics.SetActiveConnections(b.URL.String(), b.ActiveConnections())
+		s.metrics.SetCircuitBreakerState(b.URL.String(), b.GetCircuitBreakerState())
+	}
+}
+
+// httpHealthCheck is the HealthCheck path taken once EnableHealthChecks has
+// configured a probe: it first checks passive outlier ejection
+// (RecordMetrics-derived error rate over the configured window), then, for
+// any backend not currently ejected, runs probeBackend's active probe (HTTP,
+// TCP, or gRPC, per Backend.ProbeType) and updates
+// Alive/CircuitBreaker/logging from the result.
+func (s *ServerPool) httpHealthCheck(b *Backend) {
+	opts := s.healthCheckOpts
+	now := time.Now()
+
+	if !b.isEjected(now) {
+		if rate, samples := b.errorRate(now, opts.OutlierWindow); samples >= opts.OutlierMinSamples && rate > opts.OutlierErrorRateThreshold {
+			count := b.eject(now, opts.EjectionBackoff)
+			s.ejectBackend(b, rate, count)
+			return
+		}
+	} else {
+		// Still inside the backoff window - skip probing and leave Alive as
+		// the down state ejectBackend() put it in.
+		return
+	}
+
+	probeErr := s.probeBackend(b)
+	wasAlive := b.IsAlive()
+	if probeErr != nil {
+		b.SetAlive(false)
+		if b.GetCircuitBreakerState() != loadbalancer.StateOpen && b.CircuitBreaker != nil {
+			b.CircuitBreaker.ForceState(loadbalancer.StateOpen)
+		}
+		log.Printf("[WARN] Backend %s health probe failed: %v", b.URL.String(), probeErr)
+		if wasAlive {
+			s.log().Warn("backend marked down",
+				loadbalancer.String("backend_url", b.URL.String()),
+				loadbalancer.String("region", b.Region),
+				loadbalancer.Int("weight", b.GetWeight()),
+				loadbalancer.String("circuit_state", b.GetCircuitBreakerState().String()),
+				loadbalancer.Int64("active_conns", b.ActiveConnections()),
+				loadbalancer.Err(probeErr),
+			)
+		}
+		return
+	}
+
+	b.SetAlive(true)
+	wasEjected := b.ejectionCount > 0
+	if wasEjected {
+		b.reinstate()
+		if b.CircuitBreaker != nil {
+			b.CircuitBreaker.ForceState(loadbalancer.StateClosed)
+		}
+		s.log().Info("backend reinstated after probe success",
+			loadbalancer.String("backend_url", b.URL.String()),
+			loadbalancer.String("region", b.Region),
+		)
+	}
+	if !wasAlive {
+		s.log().Info("backend marked up",
+			loadbalancer.String("backend_url", b.URL.String()),
+			loadbalancer.String("region", b.Region),
+			loadbalancer.Int("weight", b.GetWeight()),
+		)
+	}
+}
+
+// ejectBackend passively ejects b: it trips the circuit breaker, marks it
+// dead, and logs the outlier-error-rate decision that triggered it.
+func (s *ServerPool) ejectBackend(b *Backend, errorRate float64, ejectionCount int) {
+	b.SetAlive(false)
+	if b.CircuitBreaker != nil {
+		b.CircuitBreaker.ForceState(loadbalancer.StateOpen)
+	}
+	log.Printf("[WARN] Backend %s ejected: error rate %.2f exceeds threshold (ejection #%d)", b.URL.String(), errorRate, ejectionCount)
+	s.log().Warn("backend ejected as outlier",
+		loadbalancer.String("backend_url", b.URL.String()),
+		loadbalancer.String("region", b.Region),
+		loadbalancer.Float64("error_rate", errorRate),
+		loadbalancer.Int("ejection_count", ejectionCount),
+	)
+}
+
+// probeBackend runs one active health probe against b, picking the
+// loadbalancer.HealthProbe implementation from b.ProbeType ("http", the
+// default, "tcp", or "grpc") so different backends in the pool can be
+// probed differently. Probe latency is recorded the same way regardless of
+// probe type, so the circuit breaker and outlier ejection see gRPC/TCP
+// probe failures exactly like an HTTP 5xx.
+func (s *ServerPool) probeBackend(b *Backend) error {
+	opts := s.healthCheckOpts
+
+	probeType := b.ProbeType
+	if probeType == "" {
+		probeType = "http"
+	}
+	probe, ok := s.healthProbes[probeType]
+	if !ok {
+		return fmt.Errorf("no health probe registered for probe type %q", probeType)
+	}
+
+	target := loadbalancer.ProbeTarget{
+		Addr:           b.URL.Host,
+		ExpectedStatus: opts.ExpectedStatus,
+		BodyRegex:      opts.BodyRegex,
+		GRPCService:    b.ProbeConfig.GRPCService,
+		Timeout:        opts.Timeout,
+	}
+	if b.ProbeConfig.ExpectedStatus != nil {
+		target.ExpectedStatus = b.ProbeConfig.ExpectedStatus
+	}
+	if b.ProbeConfig.BodyRegex != nil {
+		target.BodyRegex = b.ProbeConfig.BodyRegex
+	}
+	if probeType == "http" {
+		probeURL := *b.URL
+		probeURL.Path = opts.Path
+		if b.ProbeConfig.Path != "" {
+			probeURL.Path = b.ProbeConfig.Path
 		}
+		probeURL.RawQuery = ""
+		target.URL = probeURL.String()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	result := probe.Probe(ctx, target)
+	b.recordProbeLatency(result.Latency)
+
+	if result.Healthy {
+		return nil
+	}
+	if result.Err != nil {
+		return result.Err
 	}
+	return fmt.Errorf("health probe for %s reported unhealthy", b.URL.String())
 }
 
 func (s *ServerPool) SelectBackend(region string) *Backend {
-	if region == "" || region == "default" {
+	return s.selectBackendWithStrategy(region, s.strategy)
+}
+
+// SelectBackendForService is SelectBackend scoped to one Service registered
+// via RegisterService: candidates are filtered by
+// Backend.IsAliveForService(service) instead of the pool-wide IsAlive, so a
+// probe failure against an unrelated service's HealthCheckConfig can't rule
+// a backend out here. Returns nil if no backend in service is currently
+// alive for it.
+func (s *ServerPool) SelectBackendForService(service, region string) *Backend {
+	candidates := s.getHealthyByRegionForService(service, region)
+	if len(candidates) == 0 {
+		return nil
+	}
+	return selectLeastLoaded(candidates)
+}
+
+// selectBackendWithStrategy is SelectBackend parameterized on strategy, so
+// SelectBackendWithPolicy can apply a policy's own Strategy for one request
+// without disturbing ServerPool's construction-time default.
+func (s *ServerPool) selectBackendWithStrategy(region string, strategy loadbalancer.SelectionStrategy) *Backend {
+	if strategy == loadbalancer.Weighted && (region == "" || region == "default") {
 		return s.GetNextPeerWeighted()
 	}
+
 	candidates := s.getHealthyByRegion(region)
 	if len(candidates) == 0 {
 		return s.GetNextPeerWeighted()
 	}
+
+	switch strategy {
+	case loadbalancer.RoundRobin:
+		return candidates[s.NextIndex()%len(candidates)]
+	case loadbalancer.P2C:
+		return selectP2C(candidates)
+	default: // Weighted (region-scoped), LeastLoaded
+		return selectLeastLoaded(candidates)
+	}
+}
+
+// selectLeastLoaded scans every candidate and returns the lowest-Score()
+// one. O(n) per call, and since Score() moves over time, it tends to herd
+// traffic onto whichever backend briefly looks best.
+func selectLeastLoaded(candidates []*Backend) *Backend {
 	var best *Backend
 	bestScore := math.MaxFloat64
 	for _, backend := range candidates {
-		score := backend.Score()
-		if score < bestScore {
+		if score := backend.Score(); score < bestScore {
 			best = backend
 			bestScore = score
 		}
@@ -496,12 +1934,103 @@ func (s *ServerPool) SelectBackend(region string) *Backend {
 	return best
 }
 
+// selectP2C implements power-of-two-choices: draw two distinct candidates,
+// weighted by Weight, and route to whichever has the lower Score(). Two
+// Score() calls per call (each weighted draw is a linear pass summing the
+// candidates' Weight) and within a small constant of selectLeastLoaded's optimum,
+// without comparing every candidate against the current frontrunner.
+func selectP2C(candidates []*Backend) *Backend {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	i := weightedSampleIndex(candidates, -1)
+	j := weightedSampleIndex(candidates, i)
+	a, b := candidates[i], candidates[j]
+	if a.Score() <= b.Score() {
+		return a
+	}
+	return b
+}
+
+// weightedSampleIndex returns a random index into candidates, sampled
+// proportionally to Weight (treating any Weight < 1 as 1, so an
+// unweighted/zero-weight backend is still reachable), skipping exclude (pass
+// -1 to exclude nothing).
+func weightedSampleIndex(candidates []*Backend, exclude int) int {
+	total := 0
+	for i, backend := range candidates {
+		if i == exclude {
+			continue
+		}
+		total += sampleWeight(backend)
+	}
+	if total == 0 {
+		return exclude
+	}
+	n := rand.Intn(total)
+	cum := 0
+	for i, backend := range candidates {
+		if i == exclude {
+			continue
+		}
+		cum += sampleWeight(backend)
+		if n < cum {
+			return i
+		}
+	}
+	return exclude
+}
+
+func sampleWeight(backend *Backend) int {
+	if backend.Weight < 1 {
+		return 1
+	}
+	return backend.Weight
+}
+
+// getHealthyByRegionForService is getHealthyByRegion restricted to the
+// backends RegisterService registered for service and filtered by
+// Backend.IsAliveForService(service) rather than IsAlive, for
+// SelectBackendForService.
+func (s *ServerPool) getHealthyByRegionForService(service, region string) []*Backend {
+	snap := s.snapshot.Load()
+	if snap == nil {
+		return nil
+	}
+	s.serviceMux.RLock()
+	members := s.serviceBackends[service]
+	s.serviceMux.RUnlock()
+	inService := func(backend *Backend) bool {
+		_, ok := members[backend.URL.String()]
+		return ok
+	}
+	var list []*Backend
+	if region != "" {
+		for _, backend := range snap.regions[region] {
+			if inService(backend) && backend.IsAliveForService(service) {
+				list = append(list, backend)
+			}
+		}
+		if len(list) > 0 {
+			return list
+		}
+	}
+	for _, backend := range snap.backends {
+		if inService(backend) && backend.IsAliveForService(service) {
+			list = append(list, backend)
+		}
+	}
+	return list
+}
+
 func (s *ServerPool) getHealthyByRegion(region string) []*Backend {
-	s.mux.RLock()
-	defer s.mux.RUnlock()
+	snap := s.snapshot.Load()
+	if snap == nil {
+		return nil
+	}
 	var list []*Backend
 	if region != "" {
-		for _, backend := range s.regions[region] {
+		for _, backend := range snap.regions[region] {
 			if backend.IsAlive() {
 				list = append(list, backend)
 			}
@@ -510,7 +2039,7 @@ func (s *ServerPool) getHealthyByRegion(region string) []*Backend {
 			return list
 		}
 	}
-	for _, backend := range s.backends {
+	for _, backend := range snap.backends {
 		if backend.IsAlive() {
 			list = append(list, backend)
 		}
@@ -519,7 +2048,9 @@ func (s *ServerPool) getHealthyByRegion(region string) []*Backend {
 }
 
 func (s *ServerPool) Backends() []*Backend {
-	s.mux.RLock()
-	defer s.mux.RUnlock()
-	return append([]*Backend(nil), s.backends...)
+	snap := s.snapshot.Load()
+	if snap == nil {
+		return nil
+	}
+	return append([]*Backend(nil), snap.backends...)
 }
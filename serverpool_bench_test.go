@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+// newBenchPool builds a ServerPool with n backends of varying weight, all
+// alive, for the selection-path benchmarks below.
+func newBenchPool(n int) *ServerPool {
+	pool := &ServerPool{}
+	for i := 0; i < n; i++ {
+		u, _ := url.Parse("http://backend.internal")
+		backend := &Backend{
+			URL:    u,
+			Weight: (i % 5) + 1,
+		}
+		backend.SetAlive(true)
+		pool.AddBackend(backend)
+	}
+	return pool
+}
+
+// BenchmarkGetNextPeer drives round-robin selection at a level of
+// parallelism meant to stand in for a 10k-QPS workload, to compare against
+// the pre-atomic.Pointer[poolSnapshot] implementation's per-call
+// s.mux.RLock + backend-slice copy.
+func BenchmarkGetNextPeer(b *testing.B) {
+	pool := newBenchPool(20)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pool.GetNextPeer()
+		}
+	})
+}
+
+func BenchmarkGetNextPeerWeighted(b *testing.B) {
+	pool := newBenchPool(20)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pool.GetNextPeerWeighted()
+		}
+	})
+}
+
+func BenchmarkBackends(b *testing.B) {
+	pool := newBenchPool(20)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			pool.Backends()
+		}
+	})
+}